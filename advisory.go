@@ -0,0 +1,121 @@
+package pgx
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+// AdvisoryLock is session-level advisory lock held on dedicated connection of the pool
+// Additional info: https://www.postgresql.org/docs/current/explicit-locking.html#ADVISORY-LOCKS
+type AdvisoryLock struct {
+	key  int64
+	conn *sql.Conn
+	once sync.Once
+}
+
+// AdvisoryLockKey derives lock key from the name using 64-bit FNV-1a hash
+func AdvisoryLockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name)) // nolint:errcheck
+	return int64(hash.Sum64())
+}
+
+// WithAdvisoryLock waits for session-level advisory lock, runs function and releases the lock.
+// Lock and unlock are executed on the same connection, which is not used by others meanwhile.
+func WithAdvisoryLock(ctx context.Context, db *sqlx.DB, key int64, fn func() error) (err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get connection")
+	}
+
+	if _, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key); err != nil {
+		conn.Close() // nolint:errcheck
+		return errors.Wrapf(err, "could not acquire advisory lock %d", key)
+	}
+
+	lock := &AdvisoryLock{key: key, conn: conn}
+	defer func() {
+		e := lock.Unlock()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	return fn()
+}
+
+// TryAdvisoryLock acquires session-level advisory lock if it is available without waiting.
+// The lock is held on dedicated connection until Unlock is called, nil lock is returned
+// when it is held by other session.
+func TryAdvisoryLock(ctx context.Context, db *sqlx.DB, key int64) (*AdvisoryLock, error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get connection")
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close() // nolint:errcheck
+		return nil, errors.Wrapf(err, "could not acquire advisory lock %d", key)
+	}
+
+	return &AdvisoryLock{key: key, conn: conn}, nil
+}
+
+// Key returns lock key
+func (l *AdvisoryLock) Key() int64 {
+	return l.key
+}
+
+// Unlock releases the lock and returns connection to the pool, subsequent calls do nothing.
+// Connection is closed instead when the lock could not be released, so the lock is not leaked
+// to other users of the pool.
+func (l *AdvisoryLock) Unlock() (err error) {
+	l.once.Do(func() {
+		// Lock must be released even if the context of locking is done
+		var released bool
+		err = l.conn.QueryRowContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key).Scan(&released)
+		if err != nil {
+			discardConn(l.conn)
+			err = errors.Wrapf(err, "could not release advisory lock %d", l.key)
+			return
+		}
+		if !released {
+			err = errors.Errorf("could not release advisory lock %d: lock is not held", l.key)
+		}
+
+		e := l.conn.Close()
+		if err == nil {
+			err = errors.Wrap(e, "could not close connection")
+		}
+	})
+
+	return err
+}
+
+// discardConn terminates the session of the connection, so session-level locks are released.
+// pq reports termination as bad connection, so the pool closes the connection instead of reusing it.
+func discardConn(conn *sql.Conn) {
+	conn.ExecContext(context.Background(), "SELECT pg_terminate_backend(pg_backend_pid())") // nolint:errcheck
+	conn.Close()                                                                            // nolint:errcheck
+}
+
+// AdvisoryTxLock waits for transaction-level advisory lock,
+// it is released automatically when the transaction ends
+func AdvisoryTxLock(ctx context.Context, tx *sqlx.Tx, key int64) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", key)
+	return errors.Wrapf(err, "could not acquire advisory lock %d", key)
+}
+
+// TryAdvisoryTxLock acquires transaction-level advisory lock if it is available without waiting,
+// it is released automatically when the transaction ends
+func TryAdvisoryTxLock(ctx context.Context, tx *sqlx.Tx, key int64) (acquired bool, err error) {
+	err = tx.GetContext(ctx, &acquired, "SELECT pg_try_advisory_xact_lock($1)", key)
+	return acquired, errors.Wrapf(err, "could not acquire advisory lock %d", key)
+}
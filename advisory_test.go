@@ -0,0 +1,131 @@
+package pgx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type AdvisoryLockPositiveSuite struct {
+	suite.Suite
+	options *Options
+	db      *sqlx.DB
+	key     int64
+}
+
+func (s *AdvisoryLockPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+	s.key = AdvisoryLockKey("pgx-test")
+}
+
+func (s *AdvisoryLockPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+
+	opts := *s.options
+	opts.MaxOpenConns = 3
+
+	var err error
+	s.db, err = Connect(&opts)
+	s.Require().NoError(err)
+}
+
+func (s *AdvisoryLockPositiveSuite) TearDownTest() {
+	s.Require().NoError(s.db.Close())
+	s.Require().NoError(Drop(s.options))
+}
+
+func (s *AdvisoryLockPositiveSuite) TestWithAdvisoryLock() {
+	err := WithAdvisoryLock(context.Background(), s.db, s.key, func() error {
+		lock, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+		s.NoError(err)
+		s.Nil(lock, "Lock must be held by other session")
+		return errors.New("failed")
+	})
+	s.EqualError(err, "failed")
+
+	lock, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+	s.Require().NoError(err)
+	s.Require().NotNil(lock, "Lock must be released")
+	s.NoError(lock.Unlock())
+}
+
+func (s *AdvisoryLockPositiveSuite) TestTryAdvisoryLock() {
+	lock, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+	s.Require().NoError(err)
+	s.Require().NotNil(lock)
+	s.Equal(s.key, lock.Key())
+
+	other, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+	s.NoError(err)
+	s.Nil(other, "Lock must be held by other session")
+
+	s.NoError(lock.Unlock())
+	s.NoError(lock.Unlock(), "Unlock must be idempotent")
+
+	other, err = TryAdvisoryLock(context.Background(), s.db, s.key)
+	s.Require().NoError(err)
+	s.Require().NotNil(other, "Lock must be released")
+	s.NoError(other.Unlock())
+}
+
+func (s *AdvisoryLockPositiveSuite) TestUnlockFailure() {
+	// Connection could be leaked only when it is kept idle
+	s.db.SetMaxIdleConns(3)
+
+	lock, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+	s.Require().NoError(err)
+	s.Require().NotNil(lock)
+
+	// Session stays alive, but rejects queries until the transaction ends
+	_, err = lock.conn.ExecContext(context.Background(), "BEGIN")
+	s.Require().NoError(err)
+	_, err = lock.conn.ExecContext(context.Background(), "SELECT 1/0")
+	s.Require().Error(err)
+
+	s.Error(lock.Unlock())
+
+	for i := 0; i < 3; i++ {
+		other, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+		s.Require().NoError(err)
+		s.Require().NotNil(other, "Lock must not be leaked to the pool")
+		s.NoError(other.Unlock())
+	}
+}
+
+func (s *AdvisoryLockPositiveSuite) TestAdvisoryTxLock() {
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		if err := AdvisoryTxLock(context.Background(), tx, s.key); err != nil {
+			return err
+		}
+
+		lock, err := TryAdvisoryLock(context.Background(), s.db, s.key)
+		s.NoError(err)
+		s.Nil(lock, "Lock must be held by transaction")
+		return nil
+	})
+	s.Require().NoError(err)
+
+	err = WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		acquired, err := TryAdvisoryTxLock(context.Background(), tx, s.key)
+		s.True(acquired, "Lock must be released with transaction")
+		return err
+	})
+	s.NoError(err)
+}
+
+// Run tests
+func TestAdvisoryLockKey(t *testing.T) {
+	assert.Equal(t, int64(-3750763034362895579), AdvisoryLockKey(""), "Must use FNV-1a hash")
+	assert.Equal(t, AdvisoryLockKey("cron:report"), AdvisoryLockKey("cron:report"), "Must be stable")
+	assert.NotEqual(t, AdvisoryLockKey("cron:report"), AdvisoryLockKey("cron:cleanup"))
+}
+
+func TestAdvisoryLockPositiveSuite(t *testing.T) {
+	suite.Run(t, new(AdvisoryLockPositiveSuite))
+}
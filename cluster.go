@@ -0,0 +1,242 @@
+package pgx
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultHealthCheckInterval is used when ClusterOptions.HealthCheckInterval is not set
+	defaultHealthCheckInterval = 5 * time.Second
+	// replicationLagQuery returns replication lag in seconds, it is zero on not replica servers
+	// and when replica replayed all received WAL, so idle primary does not look lagging.
+	// It is NULL when WAL receiver is not streaming, since received WAL says nothing about the primary then.
+	// Status is visible only to pg_read_all_stats members, so for others it is enough that receiver runs.
+	replicationLagQuery = `SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE COALESCE(status, 'streaming') = 'streaming')
+			THEN NULL
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+)
+
+// ClusterOptions is parameters of replicas health checks
+type ClusterOptions struct {
+	// The interval between health checks of replicas. Default is 5s.
+	HealthCheckInterval time.Duration `yaml:"health_check_interval" json:"health_check_interval"`
+	// Replicas lagging behind the primary more than this are not used for reads. Zero means no limit.
+	// Replicas not streaming WAL from the primary are never used, since their lag is unknown.
+	MaxReplicationLag time.Duration `yaml:"max_replication_lag" json:"max_replication_lag"`
+}
+
+// Cluster routes writes and transactions to the primary and balances reads across healthy replicas,
+// reads are routed to the primary when no replica is healthy
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	next     uint32
+	opts     ClusterOptions
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// replica is replica pool with its health state
+type replica struct {
+	db      *sqlx.DB
+	healthy int32
+}
+
+// ConnectCluster connects to the primary and replicas and starts health checks of replicas
+func ConnectCluster(primary *Options, replicas []*Options, clusterOpts *ClusterOptions) (*Cluster, error) {
+	return ConnectClusterContext(context.Background(), primary, replicas, clusterOpts)
+}
+
+// ConnectClusterContext connects to the primary and replicas using the given context
+// and starts health checks of replicas.
+// Unavailable replicas do not fail connection, they are used for reads once health check passes.
+func ConnectClusterContext(
+	ctx context.Context, primary *Options, replicas []*Options, clusterOpts *ClusterOptions,
+) (*Cluster, error) {
+	primaryDB, err := ConnectContext(ctx, primary)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect to primary")
+	}
+
+	c := &Cluster{
+		primary:  primaryDB,
+		replicas: make([]*replica, 0, len(replicas)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if clusterOpts != nil {
+		c.opts = *clusterOpts
+	}
+	if c.opts.HealthCheckInterval <= 0 {
+		c.opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+
+	for _, opts := range replicas {
+		c.replicas = append(c.replicas, &replica{db: newPool(opts, NewConnector(opts))})
+	}
+
+	c.checkReplicas(ctx)
+	go c.runHealthChecks()
+
+	return c, nil
+}
+
+// Primary returns the primary pool
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// Replica returns the next healthy replica pool in round-robin order or the primary pool
+// when no replica is healthy
+func (c *Cluster) Replica() *sqlx.DB {
+	healthy := make([]*sqlx.DB, 0, len(c.replicas))
+	for _, r := range c.replicas {
+		if atomic.LoadInt32(&r.healthy) == 1 {
+			healthy = append(healthy, r.db)
+		}
+	}
+	if len(healthy) == 0 {
+		return c.primary
+	}
+
+	return healthy[atomic.AddUint32(&c.next, 1)%uint32(len(healthy))]
+}
+
+// Exec executes query on the primary
+func (c *Cluster) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.ExecContext(context.Background(), query, args...)
+}
+
+// ExecContext executes query on the primary using the given context
+func (c *Cluster) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return c.primary.ExecContext(ctx, query, args...)
+}
+
+// Beginx begins transaction on the primary
+func (c *Cluster) Beginx() (*sqlx.Tx, error) {
+	return c.BeginTxx(context.Background(), nil)
+}
+
+// BeginTxx begins transaction on the primary using the given context
+func (c *Cluster) BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error) {
+	return c.primary.BeginTxx(ctx, opts)
+}
+
+// Get reads single row into dest from replica
+func (c *Cluster) Get(dest interface{}, query string, args ...interface{}) error {
+	return c.GetContext(context.Background(), dest, query, args...)
+}
+
+// GetContext reads single row into dest from replica using the given context
+func (c *Cluster) GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.Replica().GetContext(ctx, dest, query, args...)
+}
+
+// Select reads rows into dest from replica
+func (c *Cluster) Select(dest interface{}, query string, args ...interface{}) error {
+	return c.SelectContext(context.Background(), dest, query, args...)
+}
+
+// SelectContext reads rows into dest from replica using the given context
+func (c *Cluster) SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error {
+	return c.Replica().SelectContext(ctx, dest, query, args...)
+}
+
+// Queryx queries rows from replica
+func (c *Cluster) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	return c.QueryxContext(context.Background(), query, args...)
+}
+
+// QueryxContext queries rows from replica using the given context
+func (c *Cluster) QueryxContext(ctx context.Context, query string, args ...interface{}) (*sqlx.Rows, error) {
+	return c.Replica().QueryxContext(ctx, query, args...)
+}
+
+// QueryRowx queries single row from replica
+func (c *Cluster) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	return c.QueryRowxContext(context.Background(), query, args...)
+}
+
+// QueryRowxContext queries single row from replica using the given context
+func (c *Cluster) QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row {
+	return c.Replica().QueryRowxContext(ctx, query, args...)
+}
+
+// Close stops health checks and closes all pools
+func (c *Cluster) Close() (err error) {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+		<-c.done
+
+		err = errors.Wrap(c.primary.Close(), "could not close primary")
+		for _, r := range c.replicas {
+			if e := r.db.Close(); e != nil && err == nil {
+				err = errors.Wrap(e, "could not close replica")
+			}
+		}
+	})
+
+	return err
+}
+
+// runHealthChecks checks replicas periodically until the cluster is closed
+func (c *Cluster) runHealthChecks() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(c.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.checkReplicas(context.Background())
+		}
+	}
+}
+
+// checkReplicas checks all replicas concurrently, every check is limited by health check interval
+func (c *Cluster) checkReplicas(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.HealthCheckInterval)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func(r *replica) {
+			defer wg.Done()
+
+			var healthy int32
+			if c.isHealthy(ctx, r.db) {
+				healthy = 1
+			}
+			atomic.StoreInt32(&r.healthy, healthy)
+		}(r)
+	}
+	wg.Wait()
+}
+
+// isHealthy checks that replica is available, streams WAL from the primary
+// and its replication lag is acceptable
+func (c *Cluster) isHealthy(ctx context.Context, db *sqlx.DB) bool {
+	var lag sql.NullFloat64
+	if err := db.GetContext(ctx, &lag, replicationLagQuery); err != nil || !lag.Valid {
+		return false
+	}
+
+	return c.opts.MaxReplicationLag <= 0 ||
+		time.Duration(lag.Float64*float64(time.Second)) <= c.opts.MaxReplicationLag
+}
@@ -0,0 +1,104 @@
+package pgx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type ClusterPositiveSuite struct {
+	suite.Suite
+	options *Options
+}
+
+func (s *ClusterPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+}
+
+func (s *ClusterPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+}
+
+func (s *ClusterPositiveSuite) TearDownTest() {
+	s.Require().NoError(DropForce(s.options))
+}
+
+func (s *ClusterPositiveSuite) TestConnectCluster() {
+	unavailable := *s.options
+	unavailable.Host = "127.0.0.1"
+	unavailable.Port = 1
+
+	cluster, err := ConnectCluster(s.options, []*Options{s.options, &unavailable}, &ClusterOptions{
+		HealthCheckInterval: time.Second,
+		MaxReplicationLag:   time.Minute,
+	})
+	s.Require().NoError(err)
+	defer func() {
+		s.NoError(cluster.Close())
+	}()
+
+	s.Equal(int32(1), cluster.replicas[0].healthy, "Available replica must be healthy")
+	s.Equal(int32(0), cluster.replicas[1].healthy, "Unavailable replica must be ejected")
+	for i := 0; i < 4; i++ {
+		s.Equal(cluster.replicas[0].db, cluster.Replica(), "Reads must be routed to healthy replica")
+	}
+
+	_, err = cluster.Exec("CREATE TABLE users (id INTEGER)")
+	s.Require().NoError(err)
+	_, err = cluster.Exec("INSERT INTO users VALUES (1)")
+	s.Require().NoError(err)
+
+	var count int
+	s.NoError(cluster.Get(&count, "SELECT COUNT(*) FROM users"))
+	s.Equal(1, count)
+
+	tx, err := cluster.Beginx()
+	s.Require().NoError(err)
+	s.NoError(tx.Rollback())
+}
+
+func (s *ClusterPositiveSuite) TestClose() {
+	cluster, err := ConnectCluster(s.options, nil, nil)
+	s.Require().NoError(err)
+
+	s.NoError(cluster.Close())
+	s.NoError(cluster.Close(), "Close must be idempotent")
+	s.Error(cluster.Primary().Ping(), "Primary must be closed")
+}
+
+// Run tests
+func TestClusterReplica(t *testing.T) {
+	opts := &Options{Host: "localhost", Port: 5432}
+	cluster := &Cluster{
+		primary: newPool(opts, NewConnector(opts)),
+		replicas: []*replica{
+			{db: newPool(opts, NewConnector(opts)), healthy: 1},
+			{db: newPool(opts, NewConnector(opts)), healthy: 0},
+			{db: newPool(opts, NewConnector(opts)), healthy: 1},
+		},
+	}
+
+	used := map[interface{}]int{}
+	for i := 0; i < 6; i++ {
+		used[cluster.Replica()]++
+	}
+	assert.Equal(t, map[interface{}]int{
+		cluster.replicas[0].db: 3,
+		cluster.replicas[2].db: 3,
+	}, used, "Must balance reads across healthy replicas")
+
+	cluster.replicas[0].healthy = 0
+	cluster.replicas[2].healthy = 0
+	assert.Equal(t, cluster.primary, cluster.Replica(), "Must fall back to primary")
+
+	cluster.replicas = nil
+	assert.Equal(t, cluster.primary, cluster.Replica(), "Must use primary without replicas")
+}
+
+func TestClusterPositiveSuite(t *testing.T) {
+	suite.Run(t, new(ClusterPositiveSuite))
+}
@@ -0,0 +1,175 @@
+package pgx
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// CredentialsProvider provides credentials for every new physical connection,
+// so long-lived pools pick up rotated passwords
+type CredentialsProvider interface {
+	// Credentials returns user and password, empty user keeps the one from options
+	Credentials(ctx context.Context) (user, password string, err error)
+}
+
+// connector creates connections by options, credentials are resolved for every connection
+type connector struct {
+	opts Options
+}
+
+// Connect implements driver.Connector interface, hosts are tried in order until one accepts the connection
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	hosts, err := c.opts.hostOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range hosts {
+		var conn driver.Conn
+		conn, err = connectHost(ctx, &hosts[i], c.opts.TargetSessionAttrs)
+		if err == nil || ctx.Err() != nil {
+			return conn, err
+		}
+	}
+
+	return nil, err
+}
+
+// connectHost creates connection to the single host and checks its session type
+func connectHost(ctx context.Context, opts *Options, targetSessionAttrs string) (driver.Conn, error) {
+	conn, err := openConn(ctx, opts)
+	if err != nil || targetSessionAttrs != targetSessionReadWrite {
+		return conn, err
+	}
+
+	if err := checkReadWrite(ctx, conn); err != nil {
+		conn.Close() // nolint:errcheck
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// openConn creates connection to the single host,
+// custom dialer and TLS are used by connDialer since pq.Connector does not support them
+func openConn(ctx context.Context, opts *Options) (driver.Conn, error) {
+	customTLS := opts.hasCustomTLS() && opts.SSLMode != "disable"
+	if !customTLS && opts.Dialer == nil {
+		dbURL, err := connectionURL(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		pqConnector, err := pq.NewConnector(dbURL)
+		if err != nil {
+			return nil, errors.Wrap(stripURL(err), "could not create connector")
+		}
+
+		return pqConnector.Connect(ctx)
+	}
+
+	dialer := &openDialer{connDialer: &connDialer{dialer: opts.Dialer}, ctx: ctx}
+	if dialer.dialer == nil {
+		dialer.dialer = &netDialer{}
+	}
+
+	connOpts := *opts
+	if customTLS {
+		config, err := buildTLSConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		dialer.tls = config
+		dialer.required = opts.SSLMode != "allow" && opts.SSLMode != "prefer"
+
+		// pq must not start TLS since the dialer does it
+		connOpts.SSLMode = "disable"
+		connOpts.SSLCert, connOpts.SSLKey, connOpts.SSLRootCert = "", "", ""
+	}
+
+	dbURL, err := connectionURL(ctx, &connOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := pq.DialOpen(dialer, dbURL)
+	dialer.opened()
+	return conn, stripURL(err)
+}
+
+// checkReadWrite checks that server accepts read-write transactions
+func checkReadWrite(ctx context.Context, conn driver.Conn) error {
+	value, err := queryValue(ctx, conn, "SHOW transaction_read_only")
+	if err != nil {
+		return errors.Wrap(err, "could not check session type")
+	}
+	if fmt.Sprintf("%s", value) != "off" {
+		return errors.New("server is in read-only mode")
+	}
+
+	return nil
+}
+
+// queryValue queries single value on the driver connection, which is not wrapped by the pool yet
+func queryValue(ctx context.Context, conn driver.Conn, query string) (value driver.Value, err error) {
+	queryer, ok := conn.(driver.QueryerContext)
+	if !ok {
+		return nil, errors.New("connection does not support queries")
+	}
+
+	rows, err := queryer.QueryContext(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		e := rows.Close()
+		if e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		return nil, err
+	}
+
+	return dest[0], nil
+}
+
+// Driver implements driver.Connector interface
+func (c *connector) Driver() driver.Driver {
+	return &pq.Driver{}
+}
+
+// NewConnector returns driver.Connector creating connections by options,
+// e.g. to wrap it with per-connection hooks and pass to ConnectWithConnector
+func NewConnector(opts *Options) driver.Connector {
+	return &connector{opts: *opts}
+}
+
+// connectionURL builds database connection URL with resolved credentials
+func connectionURL(ctx context.Context, opts *Options) (string, error) {
+	if opts.Credentials != nil {
+		user, password, err := opts.Credentials.Credentials(ctx)
+		if err != nil {
+			return "", errors.Wrap(err, "could not get credentials")
+		}
+
+		credsOpts := *opts
+		if user != "" {
+			credsOpts.User = user
+		}
+		return buildURL(&credsOpts, password), nil
+	}
+
+	password, err := opts.resolvePassword()
+	if err != nil {
+		return "", errors.Wrap(err, "could not resolve password")
+	}
+
+	return buildURL(opts, password), nil
+}
@@ -0,0 +1,303 @@
+package pgx
+
+import (
+	"context"
+	"database/sql/driver"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+// countingProvider returns static credentials and counts calls
+type countingProvider struct {
+	mu       sync.Mutex
+	user     string
+	password string
+	err      error
+	calls    int
+}
+
+func (p *countingProvider) Credentials(ctx context.Context) (user, password string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	return p.user, p.password, p.err
+}
+
+func (p *countingProvider) Calls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls
+}
+
+// countingDialer dials by net.Dialer or returns error and counts calls
+type countingDialer struct {
+	mu        sync.Mutex
+	err       error
+	addresses []string
+}
+
+func (d *countingDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialTimeout(network, address, 0)
+}
+
+func (d *countingDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	d.mu.Lock()
+	d.addresses = append(d.addresses, address)
+	d.mu.Unlock()
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return net.DialTimeout(network, address, timeout)
+}
+
+func (d *countingDialer) Addresses() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.addresses
+}
+
+// contextDialer is countingDialer which fails when the context is done
+type contextDialer struct {
+	countingDialer
+}
+
+func (d *contextDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	d.addresses = append(d.addresses, address)
+	d.mu.Unlock()
+
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, network, address)
+}
+
+// countingConnector counts connections of the wrapped connector
+type countingConnector struct {
+	driver.Connector
+	calls int32
+}
+
+func (c *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	atomic.AddInt32(&c.calls, 1)
+	return c.Connector.Connect(ctx)
+}
+
+// Positive suite
+type ConnectorPositiveSuite struct {
+	suite.Suite
+	options  *Options
+	provider *countingProvider
+}
+
+func (s *ConnectorPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+	s.provider = &countingProvider{user: s.options.User, password: s.options.Password}
+}
+
+func (s *ConnectorPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+	s.provider.calls = 0
+}
+
+func (s *ConnectorPositiveSuite) TearDownTest() {
+	s.Require().NoError(Drop(s.options))
+}
+
+func (s *ConnectorPositiveSuite) TestConnect() {
+	opts := *s.options
+	opts.Password = "wrong"
+	opts.MaxOpenConns = 2
+	opts.Credentials = s.provider
+
+	db, err := Connect(&opts)
+	s.Require().NoError(err)
+	defer db.Close() // nolint:errcheck
+
+	tx, err := db.Begin()
+	s.Require().NoError(err)
+	defer tx.Rollback() // nolint:errcheck
+	s.NoError(db.Ping())
+
+	// Pool keeps no idle connections, so ping of Connect, transaction and ping use separate connections
+	s.Equal(3, s.provider.Calls(), "Provider must be called for every connection")
+}
+
+func (s *ConnectorPositiveSuite) TestMigrateUp() {
+	opts := *s.options
+	opts.Password = "wrong"
+	opts.Credentials = s.provider
+
+	s.NoError(MigrateUp(&opts, "testdata/migrations"))
+	s.True(s.provider.Calls() > 0, "Provider must be called for migrations")
+}
+
+func (s *ConnectorPositiveSuite) TestConnectFailover() {
+	opts := *s.options
+	opts.Hosts = []string{"127.0.0.1:1", net.JoinHostPort(opts.Host, strconv.Itoa(int(opts.Port)))}
+	opts.TargetSessionAttrs = "read-write"
+
+	db, err := Connect(&opts)
+	s.Require().NoError(err)
+	s.NoError(db.Ping())
+	s.NoError(db.Close())
+}
+
+func (s *ConnectorPositiveSuite) TestConnectDialer() {
+	opts := *s.options
+	dialer := &countingDialer{}
+	opts.Dialer = dialer
+
+	db, err := Connect(&opts)
+	s.Require().NoError(err)
+	s.NoError(db.Close())
+
+	s.Len(dialer.Addresses(), 1, "Dialer must be used for connections")
+}
+
+func (s *ConnectorPositiveSuite) TestCancelAfterConnectContext() {
+	opts := *s.options
+	opts.Dialer = &contextDialer{}
+
+	connectCtx, cancelConnect := context.WithCancel(context.Background())
+	db, err := ConnectContext(connectCtx, &opts)
+	s.Require().NoError(err)
+	defer db.Close() // nolint:errcheck
+
+	// Pool keeps no idle connections, so the connection opened by the context is held explicitly
+	conn, err := db.Conn(connectCtx)
+	s.Require().NoError(err)
+	defer conn.Close() // nolint:errcheck
+	cancelConnect()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = conn.ExecContext(ctx, "SELECT pg_sleep(10)")
+	s.Error(err)
+	s.True(time.Since(start) < 5*time.Second, "Query must be canceled on the server")
+}
+
+func (s *ConnectorPositiveSuite) TestConnectWithConnector() {
+	connector := &countingConnector{Connector: NewConnector(s.options)}
+
+	db, err := ConnectWithConnector(s.options, connector)
+	s.Require().NoError(err)
+	s.NoError(db.Ping())
+	s.NoError(db.Close())
+
+	// Pool keeps no idle connections, so ping of Connect and ping use separate connections
+	s.Equal(int32(2), atomic.LoadInt32(&connector.calls), "Connector must be used for connections")
+}
+
+func (s *ConnectorPositiveSuite) TestConnectProviderError() {
+	opts := *s.options
+	opts.Credentials = &countingProvider{err: errors.New("vault is sealed")}
+
+	_, err := Connect(&opts)
+	s.Error(err)
+}
+
+// Run tests
+func TestConnectionURL(t *testing.T) {
+	opts := &Options{User: "admin", Password: "static", Host: "localhost", Port: 5432}
+
+	dbURL, err := connectionURL(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Contains(t, dbURL, "admin:static@", "Must use static password")
+
+	opts.Credentials = &countingProvider{password: "rotated"}
+	dbURL, err = connectionURL(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Contains(t, dbURL, "admin:rotated@", "Must keep user and use provided password")
+
+	opts.Credentials = &countingProvider{user: "app", password: "rotated"}
+	dbURL, err = connectionURL(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Contains(t, dbURL, "app:rotated@", "Must use provided user")
+
+	opts.Credentials = &countingProvider{err: errors.New("vault is sealed")}
+	_, err = connectionURL(context.Background(), opts)
+	assert.Error(t, err, "Must return provider error")
+}
+
+func TestConnectorErrorRedacted(t *testing.T) {
+	c := &connector{opts: Options{User: "admin", Password: "secret", Host: "[::1", Port: 5432}}
+
+	_, err := c.Connect(context.Background())
+	require.Error(t, err, "Must reject invalid host")
+	assert.NotContains(t, err.Error(), "secret", "Must not expose password")
+}
+
+func TestConnectDialerError(t *testing.T) {
+	dialer := &countingDialer{err: errors.New("proxy is unavailable")}
+	opts := &Options{Host: "db.local", Port: 5433, Hosts: []string{"db.local", "standby.local"}, Dialer: dialer}
+
+	_, err := Connect(opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "proxy is unavailable", "Must return dialer error")
+	assert.Equal(t, []string{"db.local:5433", "standby.local:5433"}, dialer.Addresses(), "Must dial all hosts")
+}
+
+func TestOpenDialer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() // nolint:errcheck
+
+	openCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dialer := &openDialer{connDialer: &connDialer{dialer: &contextDialer{}}, ctx: openCtx}
+
+	_, err = dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	assert.Error(t, err, "Must be limited by the context of connection attempt")
+
+	dialer.opened()
+	conn, err := dialer.DialContext(context.Background(), "tcp", listener.Addr().String())
+	require.NoError(t, err, "Cancel requests must not be limited by the context of connection attempt")
+	assert.NoError(t, conn.Close())
+}
+
+func TestHostOptions(t *testing.T) {
+	opts := &Options{Host: "/var/run/postgresql", Port: 5432, TargetSessionAttrs: "read-write"}
+	hosts, err := opts.hostOptions()
+	require.NoError(t, err)
+	require.Len(t, hosts, 1)
+	assert.Equal(t, "/var/run/postgresql", hosts[0].Host, "Must keep single host")
+	assert.Empty(t, hosts[0].TargetSessionAttrs, "Must not pass target_session_attrs to driver")
+
+	opts.Hosts = []string{"primary", "standby:5433", "[::1]:5434"}
+	hosts, err = opts.hostOptions()
+	require.NoError(t, err)
+	require.Len(t, hosts, 3)
+	assert.Equal(t, "primary", hosts[0].Host)
+	assert.Equal(t, uint(5432), hosts[0].Port, "Must use default port")
+	assert.Equal(t, "standby", hosts[1].Host)
+	assert.Equal(t, uint(5433), hosts[1].Port)
+	assert.Equal(t, "::1", hosts[2].Host)
+	assert.Nil(t, hosts[2].Hosts, "Must not keep hosts list")
+
+	opts.Hosts = []string{"primary:port"}
+	_, err = opts.hostOptions()
+	assert.Error(t, err, "Must reject invalid port")
+}
+
+func TestConnectorPositiveSuite(t *testing.T) {
+	suite.Run(t, new(ConnectorPositiveSuite))
+}
@@ -1,67 +1,386 @@
 package pgx
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"net"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
-	_ "github.com/lib/pq" // Libarary demands such import
+	"github.com/lib/pq"
 	"github.com/pkg/errors"
 )
 
+// redactedPassword replaces password in URLs for logging
+const redactedPassword = "xxxxx"
+
+// forceDropVersion is the first server version supporting DROP DATABASE ... WITH (FORCE)
+const forceDropVersion = 130000
+
+// PostgreSQL error codes
+// Additional info: https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	errCodeUniqueViolation   pq.ErrorCode = "23505"
+	errCodeDuplicateDatabase pq.ErrorCode = "42P04"
+)
+
 // Create creates database by options
 func Create(opts *Options) error {
-	return rootExec(*opts, fmt.Sprintf("CREATE DATABASE %s", opts.DBName))
+	return CreateContext(context.Background(), opts)
+}
+
+// CreateContext creates database by options using the given context
+func CreateContext(ctx context.Context, opts *Options) error {
+	if err := rootExec(ctx, *opts, buildCreateQuery(opts)); err != nil {
+		return err
+	}
+
+	return ensureOptionsExtensions(ctx, opts)
 }
 
 // Drop drops database by options
 func Drop(opts *Options) error {
-	return rootExec(*opts, fmt.Sprintf("DROP DATABASE %s", opts.DBName))
+	return DropContext(context.Background(), opts)
+}
+
+// DropContext drops database by options using the given context
+func DropContext(ctx context.Context, opts *Options) error {
+	return rootExec(ctx, *opts, "DROP DATABASE "+pq.QuoteIdentifier(opts.DBName))
+}
+
+// DropForce terminates all sessions connected to the database and drops it
+func DropForce(opts *Options) error {
+	return DropForceContext(context.Background(), opts)
+}
+
+// DropForceContext terminates all sessions connected to the database and drops it using the given context
+func DropForceContext(ctx context.Context, opts *Options) error {
+	return rootDo(ctx, *opts, func(db *sqlx.DB) error {
+		var version int
+		if err := db.GetContext(ctx, &version, "SHOW server_version_num"); err != nil {
+			return errors.Wrap(err, "could not get server version")
+		}
+
+		name := pq.QuoteIdentifier(opts.DBName)
+		if version >= forceDropVersion {
+			return execQuery(ctx, db, "DROP DATABASE "+name+" WITH (FORCE)")
+		}
+
+		if err := execQuery(ctx, db, "ALTER DATABASE "+name+" ALLOW_CONNECTIONS false"); err != nil {
+			return err
+		}
+		err := terminateBackends(ctx, db, opts.DBName)
+		if err == nil {
+			err = execQuery(ctx, db, "DROP DATABASE "+name)
+		}
+		if err != nil {
+			// Database is still here, so let it accept connections again
+			db.Exec("ALTER DATABASE " + name + " ALLOW_CONNECTIONS true") // nolint:errcheck
+			return err
+		}
+
+		return nil
+	})
+}
+
+// Exists checks whether database exists
+func Exists(opts *Options) (bool, error) {
+	return ExistsContext(context.Background(), opts)
+}
+
+// ExistsContext checks whether database exists using the given context
+func ExistsContext(ctx context.Context, opts *Options) (exists bool, err error) {
+	err = rootDo(ctx, *opts, func(db *sqlx.DB) error {
+		e := db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", opts.DBName)
+		return errors.Wrap(e, "could not check database existence")
+	})
+
+	return exists, err
+}
+
+// CreateIfNotExists creates database by options if it does not exist yet
+func CreateIfNotExists(opts *Options) error {
+	return CreateIfNotExistsContext(context.Background(), opts)
+}
+
+// CreateIfNotExistsContext creates database by options if it does not exist yet using the given context
+func CreateIfNotExistsContext(ctx context.Context, opts *Options) error {
+	exists, err := ExistsContext(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		err = rootExec(ctx, *opts, buildCreateQuery(opts))
+		// Database could be created concurrently after the existence check
+		if err != nil && !hasErrorCode(err, errCodeDuplicateDatabase, errCodeUniqueViolation) {
+			return err
+		}
+	}
+
+	return ensureOptionsExtensions(ctx, opts)
+}
+
+// DropIfExists drops database by options if it exists
+func DropIfExists(opts *Options) error {
+	return DropIfExistsContext(context.Background(), opts)
+}
+
+// DropIfExistsContext drops database by options if it exists using the given context
+func DropIfExistsContext(ctx context.Context, opts *Options) error {
+	return rootExec(ctx, *opts, "DROP DATABASE IF EXISTS "+pq.QuoteIdentifier(opts.DBName))
+}
+
+// Clone creates target database as a copy of source database,
+// sessions connected to source database are terminated since PostgreSQL requires it
+func Clone(opts *Options, sourceDB, targetDB string) error {
+	return CloneContext(context.Background(), opts, sourceDB, targetDB)
+}
+
+// CloneContext creates target database as a copy of source database using the given context
+func CloneContext(ctx context.Context, opts *Options, sourceDB, targetDB string) error {
+	target := *opts
+	target.DBName = targetDB
+	target.Template = sourceDB
+
+	return rootDo(ctx, *opts, func(db *sqlx.DB) (err error) {
+		var allowConn bool
+		err = db.GetContext(ctx, &allowConn, "SELECT datallowconn FROM pg_database WHERE datname = $1", sourceDB)
+		if err != nil {
+			return errors.Wrap(err, "could not get source database")
+		}
+
+		// Databases not accepting connections, e.g. template0, are left as is
+		if allowConn {
+			source := pq.QuoteIdentifier(sourceDB)
+			if err = execQuery(ctx, db, "ALTER DATABASE "+source+" ALLOW_CONNECTIONS false"); err != nil {
+				return err
+			}
+			defer func() {
+				// Connections must be allowed again even if the context is done
+				_, e := db.Exec("ALTER DATABASE " + source + " ALLOW_CONNECTIONS true")
+				if e != nil && err == nil {
+					err = errors.Wrap(e, "could not allow connections to source database")
+				}
+			}()
+		}
+
+		if err = terminateBackends(ctx, db, sourceDB); err != nil {
+			return err
+		}
+
+		return execQuery(ctx, db, buildCreateQuery(&target))
+	})
 }
 
 // Connect creates database connection and returns sqlx.DB pool
 func Connect(opts *Options) (*sqlx.DB, error) {
-	db, err := sqlx.Connect("postgres", BuildURL(opts))
-	if err != nil {
+	return ConnectContext(context.Background(), opts)
+}
+
+// ConnectContext creates database connection using the given context and returns sqlx.DB pool
+func ConnectContext(ctx context.Context, opts *Options) (*sqlx.DB, error) {
+	return ConnectWithConnectorContext(ctx, opts, NewConnector(opts))
+}
+
+// ConnectWithConnector creates database connection using the given connector and returns sqlx.DB pool,
+// options are used for pool and retry settings only
+func ConnectWithConnector(opts *Options, connector driver.Connector) (*sqlx.DB, error) {
+	return ConnectWithConnectorContext(context.Background(), opts, connector)
+}
+
+// ConnectWithConnectorContext creates database connection using the given connector and context
+// and returns sqlx.DB pool, options are used for pool and retry settings only
+func ConnectWithConnectorContext(
+	ctx context.Context, opts *Options, connector driver.Connector,
+) (db *sqlx.DB, err error) {
+	err = retry(ctx, opts.Retry, func() (e error) {
+		db, e = connect(ctx, opts, connector)
+		return e
+	})
+
+	return db, err
+}
+
+// connect creates database connection and checks it
+func connect(ctx context.Context, opts *Options, connector driver.Connector) (*sqlx.DB, error) {
+	db := newPool(opts, connector)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close() // nolint:errcheck
 		return nil, err
 	}
 
+	return db, nil
+}
+
+// newPool creates sqlx.DB pool with settings from options, no connections are created
+func newPool(opts *Options, connector driver.Connector) *sqlx.DB {
+	db := sqlx.NewDb(sql.OpenDB(connector), "postgres")
 	db.SetConnMaxLifetime(time.Duration(opts.ConnMaxLifetime) * time.Second)
 	db.SetMaxIdleConns(opts.MaxIdleConns)
 	db.SetMaxOpenConns(opts.MaxOpenConns)
 
-	return db, nil
+	return db
 }
 
-// BuildURL build database connection URL
+// BuildURL build database connection URL.
+// Hosts list is built in libpq multi-host form, socket directory of Host is passed as host parameter.
+// Password is resolved from PasswordFile or .pgpass file when it is empty,
+// resolving errors are ignored here and reported by Connect.
+// Credentials provider is not called, Connect calls it for every connection.
+// URL contains plaintext password, use RedactedURL for logging.
 func BuildURL(opts *Options) string {
+	password, _ := opts.resolvePassword() // nolint:gosec
+	return buildURL(opts, password)
+}
+
+// RedactedURL builds database connection URL like BuildURL with masked password,
+// so it is safe for logging
+func RedactedURL(opts *Options) string {
+	if opts.Password == "" && opts.PasswordFile == "" && opts.Credentials == nil {
+		return buildURL(opts, "")
+	}
+
+	return buildURL(opts, redactedPassword)
+}
+
+// buildURL builds database connection URL with the given password
+func buildURL(opts *Options, password string) string {
 	dbURL := &url.URL{
 		Scheme: "postgres",
-		User:   url.UserPassword(opts.User, opts.Password),
-		Host:   fmt.Sprintf("%s:%d", opts.Host, opts.Port),
-		Path:   opts.DBName,
+		User:   url.UserPassword(opts.User, password),
+	}
+	if opts.DBName != "" {
+		// Leading slash separates path from empty authority of socket URL
+		dbURL.Path = "/" + opts.DBName
 	}
 
 	query := dbURL.Query()
+	port := strconv.FormatUint(uint64(opts.Port), 10)
+	switch {
+	case len(opts.Hosts) > 0:
+		dbURL.Host = buildHostList(opts)
+	case isSocketDir(opts.Host):
+		// Socket directory could not be URL authority
+		query.Set("host", opts.Host)
+		query.Set("port", port)
+	default:
+		dbURL.Host = net.JoinHostPort(opts.Host, port)
+	}
+	if opts.TargetSessionAttrs != "" {
+		query.Set("target_session_attrs", opts.TargetSessionAttrs)
+	}
 	query.Set("connect_timeout", strconv.Itoa(opts.ConnectTimeout))
 	query.Set("sslmode", opts.SSLMode)
 	query.Set("sslcert", opts.SSLCert)
 	query.Set("sslkey", opts.SSLKey)
 	query.Set("sslrootcert", opts.SSLRootCert)
+	if opts.Schema != "" {
+		// Schema is quoted as CreateSchema does, so its name is kept as is
+		query.Set("search_path", pq.QuoteIdentifier(opts.Schema))
+	}
+	if opts.ApplicationName != "" {
+		query.Set("application_name", opts.ApplicationName)
+	}
+	if opts.StatementTimeout != 0 {
+		query.Set("statement_timeout", formatMillis(opts.StatementTimeout))
+	}
+	if opts.LockTimeout != 0 {
+		query.Set("lock_timeout", formatMillis(opts.LockTimeout))
+	}
+	if opts.IdleInTransactionSessionTimeout != 0 {
+		query.Set("idle_in_transaction_session_timeout", formatMillis(opts.IdleInTransactionSessionTimeout))
+	}
+	if opts.TimeZone != "" {
+		query.Set("TimeZone", opts.TimeZone)
+	}
+	for key, value := range opts.RuntimeParams {
+		query.Set(key, value)
+	}
 	dbURL.RawQuery = query.Encode()
 
 	return dbURL.String()
 }
 
+// formatMillis formats duration as milliseconds which is the default unit of timeout parameters
+func formatMillis(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Millisecond), 10)
+}
+
+// buildCreateQuery builds CREATE DATABASE statement from options
+func buildCreateQuery(opts *Options) string {
+	var params []string
+	if opts.Owner != "" {
+		params = append(params, "OWNER = "+pq.QuoteIdentifier(opts.Owner))
+	}
+	if opts.Template != "" {
+		params = append(params, "TEMPLATE = "+pq.QuoteIdentifier(opts.Template))
+	}
+	if opts.Encoding != "" {
+		params = append(params, "ENCODING = "+pq.QuoteLiteral(opts.Encoding))
+	}
+	if opts.LCCollate != "" {
+		params = append(params, "LC_COLLATE = "+pq.QuoteLiteral(opts.LCCollate))
+	}
+	if opts.LCCtype != "" {
+		params = append(params, "LC_CTYPE = "+pq.QuoteLiteral(opts.LCCtype))
+	}
+	if opts.Tablespace != "" {
+		params = append(params, "TABLESPACE = "+pq.QuoteIdentifier(opts.Tablespace))
+	}
+	if opts.ConnectionLimit != 0 {
+		params = append(params, fmt.Sprintf("CONNECTION LIMIT = %d", opts.ConnectionLimit))
+	}
+
+	query := "CREATE DATABASE " + pq.QuoteIdentifier(opts.DBName)
+	if len(params) > 0 {
+		query += " WITH " + strings.Join(params, " ")
+	}
+
+	return query
+}
+
+// terminateBackends terminates all sessions connected to the database except the current one
+func terminateBackends(ctx context.Context, db *sqlx.DB, dbName string) error {
+	_, err := db.ExecContext(
+		ctx,
+		"SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()",
+		dbName,
+	)
+	return errors.Wrap(err, "could not terminate backends")
+}
+
 // rootExec opens connection without database and executes one query
-func rootExec(opts Options, query string) (err error) { // nolint:gocritic
+func rootExec(ctx context.Context, opts Options, query string) error { // nolint:gocritic
+	return rootDo(ctx, opts, func(db *sqlx.DB) error {
+		return execQuery(ctx, db, query)
+	})
+}
+
+// dbExec opens connection to the database from options and executes one query
+func dbExec(ctx context.Context, opts Options, query string) error { // nolint:gocritic
+	return withDB(ctx, opts, func(db *sqlx.DB) error {
+		return execQuery(ctx, db, query)
+	})
+}
+
+// rootDo opens connection without database and passes it to the given function
+func rootDo(ctx context.Context, opts Options, fn func(*sqlx.DB) error) error { // nolint:gocritic
 	opts.DBName = ""
+	return withDB(ctx, opts, fn)
+}
+
+// withDB opens single connection to the database and passes it to the given function
+func withDB(ctx context.Context, opts Options, fn func(*sqlx.DB) error) (err error) { // nolint:gocritic
 	opts.MaxIdleConns = 0
 	opts.MaxOpenConns = 1
 
-	db, err := Connect(&opts)
+	db, err := ConnectContext(ctx, &opts)
 	if err != nil {
 		return errors.Wrap(err, "could not connect to database")
 	}
@@ -72,7 +391,28 @@ func rootExec(opts Options, query string) (err error) { // nolint:gocritic
 		}
 	}()
 
-	_, err = db.Exec(query)
+	return fn(db)
+}
+
+// hasErrorCode checks whether error is caused by PostgreSQL error with one of the given codes
+func hasErrorCode(err error, codes ...pq.ErrorCode) bool {
+	pqErr, ok := errors.Cause(err).(*pq.Error)
+	if !ok {
+		return false
+	}
+
+	for _, code := range codes {
+		if pqErr.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// execQuery executes one query without arguments
+func execQuery(ctx context.Context, db *sqlx.DB, query string) error {
+	_, err := db.ExecContext(ctx, query)
 	if err != nil {
 		return errors.Wrapf(err, "could not execute query - '%s'", query)
 	}
@@ -0,0 +1,137 @@
+package pgx
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// netDialer is the default dialer
+type netDialer struct {
+	net.Dialer
+}
+
+// DialTimeout implements pq.Dialer interface
+func (d *netDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	dialer := d.Dialer
+	dialer.Timeout = timeout
+	return dialer.Dial(network, address)
+}
+
+// connDialer dials using the underlying dialer and the context given by pq.
+// It establishes TLS itself when configuration is set, so TLS is not limited
+// by sslcert, sslkey and sslrootcert files which pq supports.
+// pq keeps the dialer of connection to send cancel requests, so it must not keep any context.
+type connDialer struct {
+	dialer   pq.Dialer
+	tls      *tls.Config
+	required bool
+}
+
+// Dial implements pq.Dialer interface
+func (d *connDialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialTimeout(network, address, 0)
+}
+
+// DialTimeout implements pq.Dialer interface, zero timeout means no timeout
+func (d *connDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return d.DialContext(ctx, network, address)
+}
+
+// DialContext implements pq.DialerContext interface
+func (d *connDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	if dialer, ok := d.dialer.(pq.DialerContext); ok {
+		conn, err = dialer.DialContext(ctx, network, address)
+	} else if deadline, ok := ctx.Deadline(); ok {
+		conn, err = d.dialer.DialTimeout(network, address, time.Until(deadline))
+	} else {
+		conn, err = d.dialer.Dial(network, address)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// SSL is not supported over Unix domain sockets
+	if d.tls == nil || network == "unix" {
+		return conn, nil
+	}
+
+	tlsConn, err := d.startTLS(ctx, conn)
+	if err != nil {
+		conn.Close() // nolint:errcheck
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// openDialer limits dialing by the context of connection attempt until the connection is opened,
+// since pq.DialOpen does not accept context. Cancel requests dialed afterwards use only their own context.
+type openDialer struct {
+	*connDialer
+
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+// DialContext implements pq.DialerContext interface
+func (d *openDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	d.mu.Lock()
+	openCtx := d.ctx
+	d.mu.Unlock()
+
+	if openCtx != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = joinContext(ctx, openCtx)
+		defer cancel()
+	}
+
+	return d.connDialer.DialContext(ctx, network, address)
+}
+
+// opened releases the context of connection attempt
+func (d *openDialer) opened() {
+	d.mu.Lock()
+	d.ctx = nil
+	d.mu.Unlock()
+}
+
+// joinContext returns context which is done when any of the given contexts is done,
+// it has the earliest deadline of them, since TLS negotiation uses it
+func joinContext(ctx, other context.Context) (context.Context, context.CancelFunc) {
+	joined, cancel := context.WithCancel(ctx)
+	if deadline, ok := other.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		joined, cancelDeadline = context.WithDeadline(joined, deadline)
+		cancelJoined := cancel
+		cancel = func() {
+			cancelDeadline()
+			cancelJoined()
+		}
+	}
+
+	go func() {
+		select {
+		case <-other.Done():
+			cancel()
+		case <-joined.Done():
+		}
+	}()
+
+	return joined, cancel
+}
@@ -0,0 +1,52 @@
+package pgx
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// envOptions maps libpq environment variables to connection string parameters
+// Additional info: https://www.postgresql.org/docs/current/libpq-envars.html
+var envOptions = []struct {
+	name string
+	key  string
+}{
+	{"PGHOST", "host"},
+	{"PGPORT", "port"},
+	{"PGUSER", "user"},
+	{"PGPASSWORD", "password"},
+	{"PGDATABASE", "dbname"},
+	{"PGSSLMODE", "sslmode"},
+	{"PGSSLCERT", "sslcert"},
+	{"PGSSLKEY", "sslkey"},
+	{"PGSSLROOTCERT", "sslrootcert"},
+	{"PGCONNECT_TIMEOUT", "connect_timeout"},
+	{"PGAPPNAME", "application_name"},
+	{"PGTZ", "TimeZone"},
+}
+
+// OptionsFromEnv loads options from standard PG* environment variables,
+// options of unset variables are left empty
+func OptionsFromEnv() (*Options, error) {
+	return OptionsFromEnvPrefix("")
+}
+
+// OptionsFromEnvPrefix loads options from PG* environment variables with the given prefix,
+// e.g. BILLING_PGHOST is read for BILLING_ prefix
+func OptionsFromEnvPrefix(prefix string) (*Options, error) {
+	opts := &Options{}
+
+	for _, env := range envOptions {
+		value, ok := os.LookupEnv(prefix + env.name)
+		if !ok {
+			continue
+		}
+
+		if err := setOption(opts, env.key, value); err != nil {
+			return nil, errors.Wrapf(err, "could not load %s%s", prefix, env.name)
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,112 @@
+package pgx
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setEnv sets environment variables and returns function restoring previous values
+func setEnv(t *testing.T, env map[string]string) func() {
+	t.Helper()
+
+	prev := make(map[string]*string, len(env))
+	for key, value := range env {
+		if prevValue, ok := os.LookupEnv(key); ok {
+			prev[key] = &prevValue
+		} else {
+			prev[key] = nil
+		}
+		require.NoError(t, os.Setenv(key, value))
+	}
+
+	return func() {
+		for key, value := range prev {
+			if value != nil {
+				require.NoError(t, os.Setenv(key, *value))
+			} else {
+				require.NoError(t, os.Unsetenv(key))
+			}
+		}
+	}
+}
+
+// clearPGEnv unsets PG* environment variables, e.g. exported by CI,
+// and returns function restoring them
+func clearPGEnv(t *testing.T) func() {
+	t.Helper()
+
+	prev := map[string]string{}
+	for _, env := range os.Environ() {
+		parts := strings.SplitN(env, "=", 2)
+		if strings.HasPrefix(parts[0], "PG") && len(parts) == 2 {
+			prev[parts[0]] = parts[1]
+			require.NoError(t, os.Unsetenv(parts[0]))
+		}
+	}
+
+	return func() {
+		for key, value := range prev {
+			require.NoError(t, os.Setenv(key, value))
+		}
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	defer clearPGEnv(t)()
+	defer setEnv(t, map[string]string{
+		"PGHOST":            "127.0.0.1",
+		"PGPORT":            "5435",
+		"PGUSER":            "admin",
+		"PGPASSWORD":        "qwerty",
+		"PGDATABASE":        "mydb",
+		"PGSSLMODE":         "verify-full",
+		"PGSSLCERT":         "./pgssl.cert",
+		"PGSSLKEY":          "./pgssl.key",
+		"PGSSLROOTCERT":     "./pgsslroot.cert",
+		"PGCONNECT_TIMEOUT": "5",
+		"PGAPPNAME":         "billing",
+		"PGTZ":              "UTC",
+	})()
+
+	opts, err := OptionsFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, &Options{
+		DBName:          "mydb",
+		User:            "admin",
+		Password:        "qwerty",
+		Host:            "127.0.0.1",
+		Port:            5435,
+		SSLMode:         "verify-full",
+		ConnectTimeout:  5,
+		SSLCert:         "./pgssl.cert",
+		SSLKey:          "./pgssl.key",
+		SSLRootCert:     "./pgsslroot.cert",
+		ApplicationName: "billing",
+		TimeZone:        "UTC",
+	}, opts, "Must load all variables")
+}
+
+func TestOptionsFromEnvPrefix(t *testing.T) {
+	defer clearPGEnv(t)()
+	defer setEnv(t, map[string]string{
+		"BILLING_PGHOST":     "billing.local",
+		"BILLING_PGDATABASE": "billing",
+		"PGHOST":             "127.0.0.1",
+	})()
+
+	opts, err := OptionsFromEnvPrefix("BILLING_")
+	require.NoError(t, err)
+	assert.Equal(t, &Options{Host: "billing.local", DBName: "billing"}, opts,
+		"Must load only prefixed variables")
+}
+
+func TestOptionsFromEnvErrors(t *testing.T) {
+	defer setEnv(t, map[string]string{"PGPORT": "port"})()
+
+	_, err := OptionsFromEnv()
+	assert.Error(t, err, "Must reject invalid port")
+}
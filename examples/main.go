@@ -1,28 +1,13 @@
 package main
 
 import (
-	"io/ioutil"
 	"log"
 
 	"github.com/cashwagon/go-pgx"
-	"gopkg.in/yaml.v2"
 )
 
-type Config = struct {
-	DBName         string `yaml:"db_name"`
-	User           string `yaml:"user"`
-	Password       string `yaml:"password"`
-	Host           string `yaml:"host"`
-	Port           uint   `yaml:"port"`
-	SSLMode        string `yaml:"ssl_mode"`
-	ConnectTimeout int    `yaml:"connect_timeout"`
-	SSLCert        string `yaml:"ssl_cert"`
-	SSLKey         string `yaml:"ssl_key"`
-	SSLRootCert    string `yaml:"ssl_root_cert"`
-}
-
 func main() {
-	options, err := loadOptions("config/config.yaml")
+	options, err := pgx.LoadOptions("config/config.yaml", &pgx.Options{MaxOpenConns: 1})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -84,32 +69,3 @@ func main() {
 		log.Fatal(err)
 	}
 }
-
-func loadOptions(filePath string) (*pgx.Options, error) {
-	cfgFile, err := ioutil.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	var config Config
-	err = yaml.Unmarshal(cfgFile, &config)
-	if err != nil {
-		return nil, err
-	}
-
-	return &pgx.Options{
-		DBName:          config.DBName,
-		User:            config.User,
-		Password:        config.Password,
-		Host:            config.Host,
-		Port:            config.Port,
-		SSLMode:         config.SSLMode,
-		ConnectTimeout:  config.ConnectTimeout,
-		SSLCert:         config.SSLCert,
-		SSLKey:          config.SSLKey,
-		SSLRootCert:     config.SSLRootCert,
-		ConnMaxLifetime: 0,
-		MaxOpenConns:    1,
-		MaxIdleConns:    0,
-	}, nil
-}
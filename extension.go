@@ -0,0 +1,78 @@
+package pgx
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// ExtensionsReport describes the result of extensions installation
+type ExtensionsReport struct {
+	// Extensions which were installed
+	Installed []string
+	// Extensions which had been already installed
+	Present []string
+}
+
+// EnsureExtensions installs extensions into the database unless they are installed already.
+// The user from options should have enough privileges to create extensions.
+func EnsureExtensions(opts *Options, extensions ...string) (*ExtensionsReport, error) {
+	return EnsureExtensionsContext(context.Background(), opts, extensions...)
+}
+
+// EnsureExtensionsContext installs extensions into the database unless they are installed already
+// using the given context
+func EnsureExtensionsContext(ctx context.Context, opts *Options, extensions ...string) (*ExtensionsReport, error) {
+	report := &ExtensionsReport{}
+
+	err := withDB(ctx, *opts, func(db *sqlx.DB) error {
+		for _, extension := range extensions {
+			var exists bool
+			err := db.GetContext(ctx, &exists,
+				"SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)", extension,
+			)
+			if err != nil {
+				return errors.Wrapf(err, "could not check extension %s", extension)
+			}
+
+			if exists {
+				report.Present = append(report.Present, extension)
+				continue
+			}
+
+			err = execQuery(ctx, db, "CREATE EXTENSION IF NOT EXISTS "+pq.QuoteIdentifier(extension))
+			if err != nil {
+				return err
+			}
+			report.Installed = append(report.Installed, extension)
+		}
+
+		return nil
+	})
+
+	return report, err
+}
+
+// ensureOptionsExtensions installs extensions listed in options.
+// Search path is reset since schema from options does not exist in the new database yet,
+// so extensions are installed into the default schema.
+func ensureOptionsExtensions(ctx context.Context, opts *Options) error {
+	if len(opts.Extensions) == 0 {
+		return nil
+	}
+
+	extOpts := *opts
+	extOpts.Schema = ""
+	extOpts.RuntimeParams = make(map[string]string, len(opts.RuntimeParams))
+	for key, value := range opts.RuntimeParams {
+		if !strings.EqualFold(key, "search_path") {
+			extOpts.RuntimeParams[key] = value
+		}
+	}
+
+	_, err := EnsureExtensionsContext(ctx, &extOpts, opts.Extensions...)
+	return err
+}
@@ -0,0 +1,91 @@
+package pgx
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type ExtensionPositiveSuite struct {
+	suite.Suite
+	options *Options
+}
+
+func (s *ExtensionPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+}
+
+func (s *ExtensionPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+}
+
+func (s *ExtensionPositiveSuite) TearDownTest() {
+	s.Require().NoError(DropIfExists(s.options))
+}
+
+func (s *ExtensionPositiveSuite) TestEnsureExtensions() {
+	s.Require().NoError(Create(s.options))
+
+	report, err := EnsureExtensions(s.options, "plpgsql", "pgcrypto")
+	s.NoError(err)
+	s.Equal([]string{"pgcrypto"}, report.Installed)
+	s.Equal([]string{"plpgsql"}, report.Present)
+
+	report, err = EnsureExtensions(s.options, "plpgsql", "pgcrypto")
+	s.NoError(err)
+	s.Empty(report.Installed)
+	s.Equal([]string{"plpgsql", "pgcrypto"}, report.Present)
+}
+
+func (s *ExtensionPositiveSuite) TestEnsureUnknownExtension() {
+	s.Require().NoError(Create(s.options))
+
+	_, err := EnsureExtensions(s.options, "pgx_unknown_extension")
+	s.Error(err)
+}
+
+func (s *ExtensionPositiveSuite) TestCreate() {
+	opts := *s.options
+	opts.Extensions = []string{"pgcrypto"}
+
+	s.NoError(Create(&opts))
+	s.assertExtensionExist(&opts, "pgcrypto")
+}
+
+func (s *ExtensionPositiveSuite) TestCreateWithSchema() {
+	opts := *s.options
+	opts.Schema = "app"
+	opts.RuntimeParams = map[string]string{"search_path": "app"}
+	opts.Extensions = []string{"pgcrypto"}
+
+	s.NoError(Create(&opts), "Must not install extensions into schema which does not exist yet")
+	s.assertExtensionExist(&opts, "pgcrypto")
+}
+
+func (s *ExtensionPositiveSuite) TestCreateIfNotExists() {
+	s.Require().NoError(Create(s.options))
+
+	opts := *s.options
+	opts.Extensions = []string{"pgcrypto"}
+
+	s.NoError(CreateIfNotExists(&opts))
+	s.assertExtensionExist(&opts, "pgcrypto")
+}
+
+func (s *ExtensionPositiveSuite) assertExtensionExist(opts *Options, extension string) {
+	db, err := Connect(opts)
+	s.Require().NoError(err)
+	defer func() {
+		s.Require().NoError(db.Close())
+	}()
+
+	var exists bool
+	s.Require().NoError(db.Get(&exists, "SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)", extension))
+	s.Truef(exists, "Extension %s should exist", extension)
+}
+
+// Run tests
+func TestExtensionPositiveSuite(t *testing.T) {
+	suite.Run(t, new(ExtensionPositiveSuite))
+}
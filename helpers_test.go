@@ -2,26 +2,14 @@ package pgx
 
 import (
 	"fmt"
-	"io/ioutil"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
-	"gopkg.in/yaml.v2"
 )
 
-type Config = struct {
-	DBName         string `yaml:"db_name"`
-	User           string `yaml:"user"`
-	Password       string `yaml:"password"`
-	Host           string `yaml:"host"`
-	Port           uint   `yaml:"port"`
-	SSLMode        string `yaml:"ssl_mode"`
-	ConnectTimeout int    `yaml:"connect_timeout"`
-}
-
 func assertSeedsVersion(t *testing.T, db *sqlx.DB, expectedVersion uint) {
 	t.Helper()
 
@@ -90,7 +78,7 @@ func isTableExist(t *testing.T, db *sqlx.DB, tableName string) bool {
 			SELECT EXISTS (
 				SELECT 1
 				FROM information_schema.tables
-				WHERE table_schema = 'public' AND table_name = $1
+				WHERE table_schema = current_schema() AND table_name = $1
 			)
 		`,
 		tableName,
@@ -103,31 +91,13 @@ func isTableExist(t *testing.T, db *sqlx.DB, tableName string) bool {
 func buildTestOptions(t *testing.T) *Options {
 	t.Helper()
 
-	config := loadTestConfig(t)
-	return &Options{
-		DBName:          config.DBName,
-		User:            config.User,
-		Password:        config.Password,
-		Host:            config.Host,
-		Port:            config.Port,
-		SSLMode:         config.SSLMode,
-		ConnectTimeout:  config.ConnectTimeout,
-		ConnMaxLifetime: 0,
-		MaxOpenConns:    1,
-		MaxIdleConns:    0,
-	}
-}
-
-func loadTestConfig(t *testing.T) Config {
-	t.Helper()
-
-	var config Config
-
-	cfgFile, err := ioutil.ReadFile("testdata/config/config.yaml")
+	// PG* environment variables must not redirect suites, which drop databases, to other servers
+	opts, err := readOptionsFile("testdata/config/config.yaml")
 	require.NoError(t, err)
 
-	err = yaml.Unmarshal(cfgFile, &config)
-	require.NoError(t, err)
+	opts.ConnMaxLifetime = 0
+	opts.MaxOpenConns = 1
+	opts.MaxIdleConns = 0
 
-	return config
+	return opts
 }
@@ -0,0 +1,78 @@
+package pgx
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Target session types
+// Additional info: https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNECT-TARGET-SESSION-ATTRS
+const (
+	targetSessionAny       = "any"
+	targetSessionReadWrite = "read-write"
+)
+
+// isSocketDir checks whether host is Unix domain socket directory
+func isSocketDir(host string) bool {
+	return strings.HasPrefix(host, "/")
+}
+
+// splitHostEntry splits "host" or "host:port" entry of hosts list, port defaults to the given one
+func splitHostEntry(entry string, defaultPort uint) (string, uint, error) {
+	host, rawPort, err := net.SplitHostPort(entry)
+	if err != nil {
+		// Entry has no port, e.g. "localhost" or "::1"
+		return strings.Trim(entry, "[]"), defaultPort, nil
+	}
+
+	port, err := strconv.ParseUint(rawPort, 10, 16)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "could not parse port of host %q", entry)
+	}
+
+	return host, uint(port), nil
+}
+
+// hostOptions returns options of every host to connect in order
+func (o *Options) hostOptions() ([]Options, error) {
+	single := *o
+	single.Hosts = nil
+	single.TargetSessionAttrs = ""
+
+	if len(o.Hosts) == 0 {
+		return []Options{single}, nil
+	}
+
+	hosts := make([]Options, 0, len(o.Hosts))
+	for _, entry := range o.Hosts {
+		host, port, err := splitHostEntry(entry, o.Port)
+		if err != nil {
+			return nil, err
+		}
+
+		hostOpts := single
+		hostOpts.Host = host
+		hostOpts.Port = port
+		hosts = append(hosts, hostOpts)
+	}
+
+	return hosts, nil
+}
+
+// buildHostList builds comma separated "host:port" list of URL authority,
+// invalid entries are kept as is
+func buildHostList(opts *Options) string {
+	entries := make([]string, 0, len(opts.Hosts))
+	for _, entry := range opts.Hosts {
+		host, port, err := splitHostEntry(entry, opts.Port)
+		if err == nil {
+			entry = net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10))
+		}
+		entries = append(entries, entry)
+	}
+
+	return strings.Join(entries, ",")
+}
@@ -0,0 +1,181 @@
+package pgx
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultElectionInterval is used when LeaderElectorOptions.Interval is not set
+	defaultElectionInterval = 5 * time.Second
+	// heldLockQuery checks whether the session holds advisory lock,
+	// bigint key is stored as high and low 32 bits in classid and objid
+	heldLockQuery = `SELECT EXISTS (
+		SELECT 1 FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid()
+		AND classid = $1 AND objid = $2 AND objsubid = 1 AND granted)`
+)
+
+// LeaderElectorOptions is leader election parameters
+type LeaderElectorOptions struct {
+	// The interval between lock attempts and heartbeats of the leader, heartbeat is limited by it too.
+	// Default is 5s.
+	Interval time.Duration
+	// OnElected is called when leadership is gained, the context is canceled when it is lost.
+	// Callbacks are called synchronously, so they must not block.
+	OnElected func(ctx context.Context)
+	// OnRevoked is called when leadership is lost, e.g. connection dies or Run returns.
+	OnRevoked func()
+}
+
+// LeaderElector elects single leader among processes by session-level advisory lock
+// held on dedicated connection of the pool
+type LeaderElector struct {
+	db     *sqlx.DB
+	key    int64
+	opts   LeaderElectorOptions
+	leader int32
+
+	conn   *sql.Conn
+	cancel context.CancelFunc
+}
+
+// NewLeaderElector creates leader elector of the given name, processes using the same name compete
+// for the leadership. The pool must allow one more connection, it is held by the elector.
+func NewLeaderElector(db *sqlx.DB, name string, opts *LeaderElectorOptions) *LeaderElector {
+	e := &LeaderElector{db: db, key: AdvisoryLockKey(name)}
+	if opts != nil {
+		e.opts = *opts
+	}
+	if e.opts.Interval <= 0 {
+		e.opts.Interval = defaultElectionInterval
+	}
+
+	return e
+}
+
+// IsLeader checks whether the elector holds the leadership
+func (e *LeaderElector) IsLeader() bool {
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+// Run campaigns for the leadership and heartbeats while it is held until the context is done,
+// then the leadership is released. It returns the context error or the error of the lock release.
+// Run must not be called concurrently.
+func (e *LeaderElector) Run(ctx context.Context) (err error) {
+	defer func() {
+		if releaseErr := e.revoke(); releaseErr != nil {
+			err = releaseErr
+		}
+	}()
+
+	ticker := time.NewTicker(e.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		e.elect(ctx)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// elect acquires the lock or checks that it is still held
+func (e *LeaderElector) elect(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.opts.Interval)
+	defer cancel()
+
+	if e.IsLeader() {
+		if err := e.heartbeat(ctx); err != nil {
+			// Connection is discarded when the lock could not be released, so it is released anyway
+			e.revoke() // nolint:errcheck
+		}
+		return
+	}
+
+	if e.conn == nil {
+		conn, err := e.db.Conn(ctx)
+		if err != nil {
+			return
+		}
+		e.conn = conn
+	}
+
+	var acquired bool
+	err := e.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", e.key).Scan(&acquired)
+	if err != nil {
+		// Connection could be dead, so it is replaced on the next attempt
+		e.closeConn() // nolint:errcheck
+		return
+	}
+	if !acquired {
+		return
+	}
+
+	leaderCtx, leaderCancel := context.WithCancel(context.Background())
+	e.cancel = leaderCancel
+	atomic.StoreInt32(&e.leader, 1)
+	if e.opts.OnElected != nil {
+		e.opts.OnElected(leaderCtx)
+	}
+}
+
+// heartbeat checks that the connection is alive and still holds the lock
+func (e *LeaderElector) heartbeat(ctx context.Context) error {
+	var held bool
+	err := e.conn.QueryRowContext(ctx, heldLockQuery, uint32(uint64(e.key)>>32), uint32(e.key)).Scan(&held)
+	if err != nil {
+		return errors.Wrap(err, "could not check advisory lock")
+	}
+	if !held {
+		return errors.New("advisory lock is lost")
+	}
+
+	return nil
+}
+
+// revoke releases the lock and the connection and notifies about lost leadership
+func (e *LeaderElector) revoke() error {
+	if !e.IsLeader() {
+		return e.closeConn()
+	}
+
+	atomic.StoreInt32(&e.leader, 0)
+	e.cancel()
+	err := e.closeConn()
+
+	if e.opts.OnRevoked != nil {
+		e.opts.OnRevoked()
+	}
+
+	return err
+}
+
+// closeConn releases the lock, so the connection could be returned to the pool.
+// The connection is discarded when the lock could not be released, otherwise the lock would be held
+// by the pool and nobody could be elected.
+func (e *LeaderElector) closeConn() error {
+	if e.conn == nil {
+		return nil
+	}
+
+	conn := e.conn
+	e.conn = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.opts.Interval)
+	defer cancel()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock_all()"); err != nil {
+		discardConn(conn)
+		return errors.Wrap(err, "could not release advisory lock")
+	}
+
+	return errors.Wrap(conn.Close(), "could not close connection")
+}
@@ -0,0 +1,166 @@
+package pgx
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+const electionInterval = 50 * time.Millisecond
+
+// Positive suite
+type LeaderElectorPositiveSuite struct {
+	suite.Suite
+	options *Options
+	db      *sqlx.DB
+}
+
+func (s *LeaderElectorPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+}
+
+func (s *LeaderElectorPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+
+	opts := *s.options
+	opts.MaxOpenConns = 3
+
+	var err error
+	s.db, err = Connect(&opts)
+	s.Require().NoError(err)
+}
+
+func (s *LeaderElectorPositiveSuite) TearDownTest() {
+	s.Require().NoError(s.db.Close())
+	s.Require().NoError(DropForce(s.options))
+}
+
+// waitFor polls condition until it is true or the timeout expires
+func (s *LeaderElectorPositiveSuite) waitFor(condition func() bool, msg string) {
+	for deadline := time.Now().Add(time.Second); !condition(); time.Sleep(electionInterval) {
+		if time.Now().After(deadline) {
+			s.Fail("Condition is not satisfied in time", msg)
+			return
+		}
+	}
+}
+
+// run runs elector in background, returned function stops it and waits for Run to return
+func (s *LeaderElectorPositiveSuite) run(e *LeaderElector) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Run(ctx)
+	}()
+
+	return func() {
+		cancel()
+		s.Equal(context.Canceled, <-done)
+	}
+}
+
+func (s *LeaderElectorPositiveSuite) TestElection() {
+	var elected, revoked int32
+	var leaderCtx context.Context
+	first := NewLeaderElector(s.db, "pgx-test", &LeaderElectorOptions{
+		Interval: electionInterval,
+		OnElected: func(ctx context.Context) {
+			leaderCtx = ctx
+			atomic.AddInt32(&elected, 1)
+		},
+		OnRevoked: func() {
+			atomic.AddInt32(&revoked, 1)
+		},
+	})
+	second := NewLeaderElector(s.db, "pgx-test", &LeaderElectorOptions{Interval: electionInterval})
+
+	stopFirst := s.run(first)
+	s.waitFor(first.IsLeader, "First elector must be elected")
+
+	stopSecond := s.run(second)
+	defer stopSecond()
+	time.Sleep(3 * electionInterval)
+	s.False(second.IsLeader(), "Second elector must wait")
+
+	stopFirst()
+	s.False(first.IsLeader())
+	s.Equal(int32(1), atomic.LoadInt32(&elected))
+	s.Equal(int32(1), atomic.LoadInt32(&revoked))
+	s.Error(leaderCtx.Err(), "Leader context must be canceled")
+
+	s.waitFor(second.IsLeader, "Second elector must take over")
+}
+
+func (s *LeaderElectorPositiveSuite) TestConnectionLoss() {
+	var revoked int32
+	e := NewLeaderElector(s.db, "pgx-test", &LeaderElectorOptions{
+		Interval: electionInterval,
+		OnRevoked: func() {
+			atomic.AddInt32(&revoked, 1)
+		},
+	})
+
+	stop := s.run(e)
+	defer stop()
+	s.waitFor(e.IsLeader, "Elector must be elected")
+
+	_, err := s.db.Exec(
+		"SELECT pg_terminate_backend(pid) FROM pg_locks WHERE locktype = 'advisory' AND pid <> pg_backend_pid()",
+	)
+	s.Require().NoError(err)
+
+	s.waitFor(func() bool {
+		return atomic.LoadInt32(&revoked) == 1
+	}, "Leadership must be revoked when connection dies")
+	s.waitFor(e.IsLeader, "Leadership must be regained with new connection")
+}
+
+func (s *LeaderElectorPositiveSuite) TestReleaseFailure() {
+	// Connection could be leaked only when it is kept idle
+	s.db.SetMaxIdleConns(3)
+
+	var elected, revoked int32
+	var e *LeaderElector
+	e = NewLeaderElector(s.db, "pgx-test", &LeaderElectorOptions{
+		Interval: electionInterval,
+		OnElected: func(ctx context.Context) {
+			if atomic.AddInt32(&elected, 1) > 1 {
+				return
+			}
+			// Session stays alive, but rejects heartbeat and unlock until the transaction ends
+			_, err := e.conn.ExecContext(ctx, "BEGIN")
+			s.NoError(err)
+			_, err = e.conn.ExecContext(ctx, "SELECT 1/0")
+			s.Error(err)
+		},
+		OnRevoked: func() {
+			atomic.AddInt32(&revoked, 1)
+		},
+	})
+
+	stop := s.run(e)
+	defer stop()
+
+	s.waitFor(func() bool {
+		return atomic.LoadInt32(&revoked) == 1
+	}, "Leadership must be revoked when heartbeat fails")
+	s.waitFor(e.IsLeader, "Lock must not be leaked to the pool")
+}
+
+// Run tests
+func TestNewLeaderElector(t *testing.T) {
+	e := NewLeaderElector(nil, "pgx-test", nil)
+	assert.Equal(t, AdvisoryLockKey("pgx-test"), e.key)
+	assert.Equal(t, defaultElectionInterval, e.opts.Interval, "Must use default interval")
+	assert.False(t, e.IsLeader())
+}
+
+func TestLeaderElectorPositiveSuite(t *testing.T) {
+	suite.Run(t, new(LeaderElectorPositiveSuite))
+}
@@ -1,47 +1,118 @@
 package pgx
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"sync/atomic"
+
 	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres" // library demands such import
-	_ "github.com/golang-migrate/migrate/v4/source/file"       // library demands such import
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file" // library demands such import
 	"github.com/pkg/errors"
 )
 
+// migrationsTable is the name of the table storing migrations version
+const migrationsTable = "schema_migrations"
+
 // MigrateUp runs migrations on the given database
 func MigrateUp(opts *Options, migrationsPath string) error {
-	return migrateUp(migrationsPath, BuildURL(opts))
+	return MigrateUpContext(context.Background(), opts, migrationsPath)
+}
+
+// MigrateUpContext runs migrations on the given database using the given context
+func MigrateUpContext(ctx context.Context, opts *Options, migrationsPath string) error {
+	return migrateUp(ctx, opts, migrationsPath, migrationsTable)
 }
 
 // MigrateDown rollbacks migrations on the given database
 func MigrateDown(opts *Options, migrationsPath string) error {
-	return migrateDown(migrationsPath, BuildURL(opts))
+	return MigrateDownContext(context.Background(), opts, migrationsPath)
+}
+
+// MigrateDownContext rollbacks migrations on the given database using the given context
+func MigrateDownContext(ctx context.Context, opts *Options, migrationsPath string) error {
+	return migrateDown(ctx, opts, migrationsPath, migrationsTable)
 }
 
 // MigrateTo runs migrations up to the given version on the given database
 func MigrateTo(opts *Options, migrationsPath string, version uint) error {
-	return migrateTo(migrationsPath, BuildURL(opts), version)
+	return MigrateToContext(context.Background(), opts, migrationsPath, version)
 }
 
-func migrateUp(migrationsPath, dbURL string) error {
-	return wrapMigration(migrationsPath, dbURL, func(m *migrate.Migrate) error {
+// MigrateToContext runs migrations up to the given version on the given database using the given context
+func MigrateToContext(ctx context.Context, opts *Options, migrationsPath string, version uint) error {
+	return migrateTo(ctx, opts, migrationsPath, migrationsTable, version)
+}
+
+func migrateUp(ctx context.Context, opts *Options, migrationsPath, table string) error {
+	return wrapMigration(ctx, opts, migrationsPath, table, func(m *migrate.Migrate) error {
 		return m.Up()
 	})
 }
 
-func migrateDown(migrationsPath, dbURL string) error {
-	return wrapMigration(migrationsPath, dbURL, func(m *migrate.Migrate) error {
+func migrateDown(ctx context.Context, opts *Options, migrationsPath, table string) error {
+	return wrapMigration(ctx, opts, migrationsPath, table, func(m *migrate.Migrate) error {
 		return m.Down()
 	})
 }
 
-func migrateTo(migrationsPath, dbURL string, version uint) error {
-	return wrapMigration(migrationsPath, dbURL, func(m *migrate.Migrate) error {
+func migrateTo(ctx context.Context, opts *Options, migrationsPath, table string, version uint) error {
+	return wrapMigration(ctx, opts, migrationsPath, table, func(m *migrate.Migrate) error {
 		return m.Migrate(version)
 	})
 }
 
-func wrapMigration(migrationsPath, dbURL string, fn func(*migrate.Migrate) error) error {
-	m, err := migrate.New("file://"+migrationsPath, dbURL)
+// backendConnector records backend process ID of the last created connection,
+// so the statement running on it could be canceled from another connection
+type backendConnector struct {
+	driver.Connector
+	pid int64
+}
+
+// Connect implements driver.Connector interface
+func (c *backendConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := queryValue(ctx, conn, "SELECT pg_backend_pid()")
+	if err != nil {
+		conn.Close() // nolint:errcheck
+		return nil, errors.Wrap(err, "could not get backend pid")
+	}
+	pid, _ := value.(int64)
+	atomic.StoreInt64(&c.pid, pid)
+
+	return conn, nil
+}
+
+// cancel cancels the statement running on the last created connection, if any
+func (c *backendConnector) cancel(opts *Options) error {
+	pid := atomic.LoadInt64(&c.pid)
+	if pid == 0 {
+		return nil
+	}
+
+	return dbExec(context.Background(), *opts, fmt.Sprintf("SELECT pg_cancel_backend(%d)", pid))
+}
+
+// wrapMigration opens migration and runs the given function, schema is recovered on failure.
+// When the context is done the run is stopped between migrations and the running migration
+// statement is canceled. The interrupted migration is rolled back only if it runs in transaction,
+// e.g. it could not be rolled back when it contains CREATE INDEX CONCURRENTLY.
+func wrapMigration(
+	ctx context.Context, opts *Options, migrationsPath, table string, fn func(*migrate.Migrate) error,
+) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "could not start migration")
+	}
+
+	m, connector, err := openMigration(ctx, opts, migrationsPath, table)
 	if err != nil {
 		return errors.Wrap(err, "could not open migration")
 	}
@@ -53,15 +124,81 @@ func wrapMigration(migrationsPath, dbURL string, fn func(*migrate.Migrate) error
 		return errors.Wrap(err, "could not get schema version")
 	}
 
-	if err := fn(m); err != nil {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			m.GracefulStop <- true
+			connector.cancel(opts) // nolint:errcheck
+		case <-done:
+		}
+	}()
+
+	err = fn(m)
+	// Cancellation must not hit statements of schema recovery
+	close(done)
+	<-stopped
+
+	if err != nil {
 		if e := recoverSchema(m, version); e != nil {
 			return errors.Wrap(err, "could not reciver schema")
 		}
 		return errors.Wrap(err, "could not migrate")
 	}
+
+	if err := ctx.Err(); err != nil {
+		return errors.Wrap(err, "migration was interrupted")
+	}
+
 	return nil
 }
 
+// openMigration opens migrations source and then database, so database is not touched
+// when migrations could not be read.
+// The database pool is limited to the single connection held by migration driver,
+// so the connector knows its backend.
+func openMigration(
+	ctx context.Context, opts *Options, migrationsPath, table string,
+) (*migrate.Migrate, *backendConnector, error) {
+	sourceDriver, err := source.Open("file://" + migrationsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		dbDriver  database.Driver
+		connector *backendConnector
+	)
+	err = retry(ctx, opts.Retry, func() error {
+		connector = &backendConnector{Connector: NewConnector(opts)}
+		db := sql.OpenDB(connector)
+		db.SetMaxOpenConns(1)
+
+		instance, e := postgres.WithInstance(db, &postgres.Config{MigrationsTable: table})
+		if e != nil {
+			db.Close() // nolint:errcheck
+			return e
+		}
+		dbDriver = instance
+		return nil
+	})
+	if err != nil {
+		sourceDriver.Close() // nolint:errcheck
+		return nil, nil, err
+	}
+
+	m, err := migrate.NewWithInstance("file", sourceDriver, "postgres", dbDriver)
+	if err != nil {
+		sourceDriver.Close() // nolint:errcheck
+		dbDriver.Close()     // nolint:errcheck
+		return nil, nil, err
+	}
+
+	return m, connector, nil
+}
+
 func recoverSchema(m *migrate.Migrate, prevVersion uint) error {
 	brokenVersion, err := getSchemaVersion(m)
 	if err != nil {
@@ -1,7 +1,9 @@
 package pgx
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/suite"
@@ -25,7 +27,7 @@ func (s *MigratePositiveSuite) SetupSuite() {
 }
 
 func (s *MigratePositiveSuite) SetupTest() {
-	Drop(s.options) // nolint:errcheck
+	s.Require().NoError(DropIfExists(s.options))
 
 	s.Require().NoError(Create(s.options))
 
@@ -77,6 +79,29 @@ func (s *MigratePositiveSuite) TestMigrateToDown() {
 	assertTableNotExist(s.T(), s.db, "users")
 }
 
+func (s *MigratePositiveSuite) TestMigrateUpContextCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.Error(MigrateUpContext(ctx, s.options, s.migrationsPath))
+
+	assertTableNotExist(s.T(), s.db, "samples")
+	assertTableNotExist(s.T(), s.db, "users")
+	s.NoError(MigrateUp(s.options, s.migrationsPath))
+}
+
+func (s *MigratePositiveSuite) TestMigrateUpContextTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	s.Error(MigrateUpContext(ctx, s.options, "testdata/migrations_slow"))
+	s.True(time.Since(start) < 30*time.Second, "Running migration must be canceled")
+
+	assertMigrationsVersion(s.T(), s.db, 0)
+	assertTableNotExist(s.T(), s.db, "samples")
+}
+
 // Negative suite with broken migrations
 type MigrateBrokenMigrationsSuite struct {
 	MigratePositiveSuite
@@ -1,28 +1,223 @@
 package pgx
 
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
 // Options is connection parameters
 // Additional info: https://godoc.org/github.com/lib/pq#hdr-Connection_String_Parameters
 type Options struct {
-	DBName         string
-	User           string
-	Password       string
-	Host           string
-	Port           uint
-	SSLMode        string
-	ConnectTimeout int
-	SSLCert        string
-	SSLKey         string
-	SSLRootCert    string
+	DBName         string `yaml:"db_name" json:"db_name"`
+	User           string `yaml:"user" json:"user"`
+	Password       string `yaml:"password" json:"password"`
+	Host           string `yaml:"host" json:"host"`
+	Port           uint   `yaml:"port" json:"port"`
+	SSLMode        string `yaml:"ssl_mode" json:"ssl_mode"`
+	ConnectTimeout int    `yaml:"connect_timeout" json:"connect_timeout"`
+	SSLCert        string `yaml:"ssl_cert" json:"ssl_cert"`
+	SSLKey         string `yaml:"ssl_key" json:"ssl_key"`
+	SSLRootCert    string `yaml:"ssl_root_cert" json:"ssl_root_cert"`
+	// PEM content of client certificate, client key and root certificate,
+	// e.g. received from secrets storage. They take precedence over the files above.
+	SSLCertPEM     string `yaml:"ssl_cert_pem" json:"ssl_cert_pem"`
+	SSLKeyPEM      string `yaml:"ssl_key_pem" json:"ssl_key_pem"`
+	SSLRootCertPEM string `yaml:"ssl_root_cert_pem" json:"ssl_root_cert_pem"`
+	// Custom TLS configuration, certificates above are added to it.
+	// Server certificate is verified by its settings instead of SSLMode, which only disables TLS.
+	TLSConfig *tls.Config `yaml:"-" json:"-"`
+	// Custom dialer of connections, e.g. SOCKS proxy or in-process test dialer.
+	// pq.DialerContext is used when it is implemented.
+	Dialer pq.Dialer `yaml:"-" json:"-"`
+	// Schema is set as search_path of every connection, so migrations and seeds
+	// tables are created in it. Empty value keeps server default.
+	Schema string `yaml:"schema" json:"schema"`
+	// Ordered list of hosts in "host" or "host:port" form, port defaults to Port.
+	// It takes precedence over Host, Connect tries hosts in order until one accepts the connection.
+	// Unix domain socket directory, e.g. /var/run/postgresql, is supported only in Host.
+	Hosts []string `yaml:"hosts" json:"hosts"`
+	// Required session type: "any" (default) or "read-write" to skip read-only hosts, e.g. standby servers.
+	TargetSessionAttrs string `yaml:"target_session_attrs" json:"target_session_attrs"`
+	// Path to the file containing password, e.g. mounted secret. It is used when Password is empty.
+	PasswordFile string `yaml:"password_file" json:"password_file"`
+	// Path to .pgpass format file. It is used when Password and PasswordFile are empty,
+	// defaults to PGPASSFILE environment variable or ~/.pgpass.
+	// Additional info: https://www.postgresql.org/docs/current/libpq-pgpass.html
+	PassFile string `yaml:"pass_file" json:"pass_file"`
+	// Credentials provider called for every new connection, it takes precedence over password options.
+	Credentials CredentialsProvider `yaml:"-" json:"-"`
+
+	// Session runtime parameters applied to every connection, empty values keep server defaults
+	// Additional info: https://www.postgresql.org/docs/current/runtime-config-client.html
+
+	// Application name shown in pg_stat_activity.
+	ApplicationName string `yaml:"application_name" json:"application_name"`
+	// Abort any statement that takes more than the specified time, it is passed in milliseconds.
+	StatementTimeout time.Duration `yaml:"statement_timeout" json:"statement_timeout"`
+	// Abort any statement that waits longer than the specified time to acquire a lock.
+	LockTimeout time.Duration `yaml:"lock_timeout" json:"lock_timeout"`
+	// Terminate any session that has been idle within an open transaction longer than the specified time.
+	IdleInTransactionSessionTimeout time.Duration `yaml:"idle_in_transaction_session_timeout" json:"idle_in_transaction_session_timeout"` // nolint:lll
+	// Time zone for displaying and interpreting time stamps.
+	TimeZone string `yaml:"time_zone" json:"time_zone"`
+	// Arbitrary runtime parameters, they take precedence over the ones above and Schema (search_path).
+	RuntimeParams map[string]string `yaml:"runtime_params" json:"runtime_params"`
 
 	// Additional parameters for database setup
 
 	// The maximum amount of time a connection may be reused.
 	// Additional info: https://golang.org/pkg/database/sql/#DB.SetConnMaxLifetime
-	ConnMaxLifetime int
+	ConnMaxLifetime int `yaml:"conn_max_lifetime" json:"conn_max_lifetime"`
 	// The maximum number of connections in the idle connection pool.
 	// Additional info: https://golang.org/pkg/database/sql/#DB.SetMaxIdleConns
-	MaxOpenConns int
+	MaxOpenConns int `yaml:"max_open_conns" json:"max_open_conns"`
 	// The maximum number of open connections to the database.
 	// Additional info: https://golang.org/pkg/database/sql/#DB.SetMaxOpenConns
-	MaxIdleConns int
+	MaxIdleConns int `yaml:"max_idle_conns" json:"max_idle_conns"`
+	// Retry policy of connection attempts failed with retriable errors.
+	// Zero value disables retries.
+	Retry RetryPolicy `yaml:"retry" json:"retry"`
+
+	// Additional parameters for database creation, empty values are omitted
+	// Additional info: https://www.postgresql.org/docs/current/sql-createdatabase.html
+
+	// The role name of the user who will own the new database.
+	Owner string `yaml:"owner" json:"owner"`
+	// The name of the template from which to create the new database.
+	Template string `yaml:"template" json:"template"`
+	// Character set encoding to use in the new database.
+	Encoding string `yaml:"encoding" json:"encoding"`
+	// Collation order (LC_COLLATE) to use in the new database.
+	LCCollate string `yaml:"lc_collate" json:"lc_collate"`
+	// Character classification (LC_CTYPE) to use in the new database.
+	LCCtype string `yaml:"lc_ctype" json:"lc_ctype"`
+	// The name of the tablespace that will be associated with the new database.
+	Tablespace string `yaml:"tablespace" json:"tablespace"`
+	// How many concurrent connections can be made to the new database.
+	// Zero omits the option, -1 means no limit.
+	ConnectionLimit int `yaml:"connection_limit" json:"connection_limit"`
+	// Extensions installed into the new database right after creation.
+	Extensions []string `yaml:"extensions" json:"extensions"`
+}
+
+// envReference matches ${VAR} references in config files
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// LoadOptions loads options from YAML or JSON file, JSON is parsed as YAML since it is its subset,
+// so durations are written the same way in both formats, e.g. "1s", or as integer nanoseconds.
+// ${VAR} references in string values are replaced with environment variables values.
+// Options are layered by precedence: file, then PG* environment variables, then the given overrides,
+// empty values of the upper layer do not override the lower one.
+func LoadOptions(path string, overrides ...*Options) (*Options, error) {
+	opts, err := readOptionsFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	envOpts, err := OptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts.merge(envOpts)
+
+	for _, override := range overrides {
+		opts.merge(override)
+	}
+
+	return opts, nil
+}
+
+// readOptionsFile reads options from the file without other layers
+func readOptionsFile(path string) (*Options, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read options file")
+	}
+
+	opts := &Options{}
+	if err := yaml.UnmarshalStrict(content, opts); err != nil {
+		return nil, errors.Wrap(err, "could not parse options file")
+	}
+	expandEnv(reflect.ValueOf(opts).Elem())
+
+	return opts, nil
+}
+
+// String implements fmt.Stringer interface, it returns URL with masked password
+func (o Options) String() string { // nolint:gocritic
+	return RedactedURL(&o)
+}
+
+// GoString implements fmt.GoStringer interface, it masks password, client key and TLS config
+func (o Options) GoString() string { // nolint:gocritic
+	if o.Password != "" {
+		o.Password = redactedPassword
+	}
+	if o.SSLKeyPEM != "" {
+		o.SSLKeyPEM = redactedPassword
+	}
+	if o.TLSConfig != nil {
+		// Only presence of TLS config is shown
+		o.TLSConfig = &tls.Config{}
+	}
+
+	// Conversion drops methods, so formatting does not recurse
+	type options Options
+	return strings.Replace(fmt.Sprintf("%#v", options(o)), "pgx.options", "pgx.Options", 1)
+}
+
+// expandEnv replaces ${VAR} references in string values with environment variables values,
+// it is done after decoding, so values are not interpreted by the file format
+func expandEnv(val reflect.Value) {
+	switch val.Kind() {
+	case reflect.String:
+		if val.CanSet() {
+			val.SetString(envReference.ReplaceAllStringFunc(val.String(), func(ref string) string {
+				return os.Getenv(envReference.FindStringSubmatch(ref)[1])
+			}))
+		}
+	case reflect.Struct:
+		for i := 0; i < val.NumField(); i++ {
+			expandEnv(val.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			expandEnv(val.Index(i))
+		}
+	case reflect.Map:
+		for _, key := range val.MapKeys() {
+			// Map values are not addressable, so they are copied and stored back
+			elem := reflect.New(val.Type().Elem()).Elem()
+			elem.Set(val.MapIndex(key))
+			expandEnv(elem)
+			val.SetMapIndex(key, elem)
+		}
+	}
+}
+
+// merge copies non-empty fields of the given options
+func (o *Options) merge(src *Options) {
+	dst := reflect.ValueOf(o).Elem()
+	val := reflect.ValueOf(src).Elem()
+
+	for i := 0; i < val.NumField(); i++ {
+		field := val.Field(i)
+		if !dst.Field(i).CanSet() {
+			continue
+		}
+		if reflect.DeepEqual(field.Interface(), reflect.Zero(field.Type()).Interface()) {
+			continue
+		}
+		dst.Field(i).Set(field)
+	}
 }
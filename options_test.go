@@ -0,0 +1,63 @@
+package pgx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadOptions(t *testing.T) {
+	// Values must not be interpreted by the file format
+	password := "qw\"er\\ty #comment\nuser: root"
+	defer clearPGEnv(t)()
+	defer setEnv(t, map[string]string{"PGX_TEST_PASSWORD": password, "PGX_TEST_SCHEMA": "app"})()
+
+	expected := &Options{
+		DBName:           "mydb",
+		User:             "admin",
+		Password:         password,
+		Host:             "127.0.0.1",
+		Port:             5435,
+		SSLMode:          "disable",
+		ConnectTimeout:   5,
+		StatementTimeout: 90 * time.Second,
+		RuntimeParams:    map[string]string{"search_path": "app"},
+		MaxOpenConns:     10,
+		MaxIdleConns:     5,
+		Retry:            RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Second},
+		Extensions:       []string{"pgcrypto"},
+	}
+
+	for _, path := range []string{"testdata/options/options.yaml", "testdata/options/options.json"} {
+		opts, err := LoadOptions(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, expected, opts, "Must load options from %s", path)
+	}
+}
+
+func TestLoadOptionsPrecedence(t *testing.T) {
+	defer clearPGEnv(t)()
+	defer setEnv(t, map[string]string{
+		"PGX_TEST_PASSWORD": "qwerty",
+		"PGHOST":            "db.local",
+		"PGDATABASE":        "envdb",
+	})()
+
+	opts, err := LoadOptions("testdata/options/options.yaml", &Options{DBName: "overridden"}, &Options{User: "app"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "db.local", opts.Host, "Environment must override file")
+	assert.Equal(t, "overridden", opts.DBName, "Overrides must override environment")
+	assert.Equal(t, "app", opts.User, "Overrides must override file")
+	assert.Equal(t, uint(5435), opts.Port, "Empty values must not override file")
+}
+
+func TestLoadOptionsErrors(t *testing.T) {
+	_, err := LoadOptions("testdata/options/missing.yaml")
+	assert.Error(t, err, "Must fail on missing file")
+
+	_, err = LoadOptions("testdata/options/unknown.yaml")
+	assert.Error(t, err, "Must reject unknown fields")
+}
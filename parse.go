@@ -0,0 +1,245 @@
+package pgx
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// defaultPort is used when connection string does not contain port
+const defaultPort = 5432
+
+// ParseURL parses postgres:// connection URL into options, it is the inverse of BuildURL
+// Additional info: https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING
+func ParseURL(rawURL string) (*Options, error) {
+	dbURL, err := url.Parse(rawURL)
+	if err != nil {
+		// Parsing error could contain any part of the URL including password, so it is not wrapped
+		return nil, errors.New("could not parse url")
+	}
+
+	if dbURL.Scheme != "postgres" && dbURL.Scheme != "postgresql" {
+		return nil, errors.Errorf("unsupported url scheme %q", dbURL.Scheme)
+	}
+
+	opts := &Options{
+		Port:   defaultPort,
+		DBName: strings.TrimPrefix(dbURL.Path, "/"),
+	}
+
+	if dbURL.User != nil {
+		opts.User = dbURL.User.Username()
+		opts.Password, _ = dbURL.User.Password()
+	}
+
+	if strings.Contains(dbURL.Host, ",") {
+		// Multi-host URL, e.g. postgres://host1:5432,host2:5432/mydb
+		opts.Hosts = strings.Split(dbURL.Host, ",")
+	} else {
+		opts.Host = dbURL.Hostname()
+		if port := dbURL.Port(); port != "" {
+			if err := setOption(opts, "port", port); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for key, values := range dbURL.Query() {
+		for _, value := range values {
+			if err := setOption(opts, key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// stripURL removes URL from url.Error since it contains password
+func stripURL(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+
+	return err
+}
+
+// ParseDSN parses libpq key=value connection string into options
+// Additional info: https://www.postgresql.org/docs/current/libpq-connect.html#LIBPQ-CONNSTRING
+func ParseDSN(dsn string) (*Options, error) {
+	opts := &Options{Port: defaultPort}
+
+	s := []rune(dsn)
+	for i := skipSpaces(s, 0); i < len(s); i = skipSpaces(s, i) {
+		start := i
+		for i < len(s) && s[i] != '=' && !unicode.IsSpace(s[i]) {
+			i++
+		}
+		key := string(s[start:i])
+
+		i = skipSpaces(s, i)
+		if i == len(s) || s[i] != '=' {
+			return nil, errors.Errorf("missing \"=\" after %q in connection string", key)
+		}
+
+		value, next, ok := readDSNValue(s, skipSpaces(s, i+1))
+		if !ok {
+			// Value is not included into error since it could be password
+			return nil, errors.Errorf("unterminated quoted value of %q in connection string", key)
+		}
+		i = next
+
+		if err := setOption(opts, key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// skipSpaces returns position of the first not space character starting from i
+func skipSpaces(s []rune, i int) int {
+	for i < len(s) && unicode.IsSpace(s[i]) {
+		i++
+	}
+
+	return i
+}
+
+// readDSNValue reads optionally quoted value with backslash escapes starting from i,
+// it returns the value and position after it
+func readDSNValue(s []rune, i int) (value string, next int, ok bool) {
+	quoted := i < len(s) && s[i] == '\''
+	if quoted {
+		i++
+	}
+
+	var runes []rune
+	for ; i < len(s); i++ {
+		if (quoted && s[i] == '\'') || (!quoted && unicode.IsSpace(s[i])) {
+			break
+		}
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		runes = append(runes, s[i])
+	}
+
+	if quoted {
+		if i == len(s) {
+			return "", i, false
+		}
+		i++
+	}
+
+	return string(runes), i, true
+}
+
+// setOption sets connection string parameter into options
+func setOption(opts *Options, key, value string) error {
+	switch key {
+	case "host":
+		if strings.Contains(value, ",") {
+			opts.Hosts = strings.Split(value, ",")
+		} else {
+			opts.Host = value
+		}
+	case "target_session_attrs":
+		opts.TargetSessionAttrs = value
+	case "port":
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return errors.Wrap(err, "could not parse port")
+		}
+		opts.Port = uint(port)
+	case "dbname":
+		opts.DBName = value
+	case "user":
+		opts.User = value
+	case "password":
+		opts.Password = value
+	case "passfile":
+		opts.PassFile = value
+	case "sslmode":
+		opts.SSLMode = value
+	case "sslcert":
+		opts.SSLCert = value
+	case "sslkey":
+		opts.SSLKey = value
+	case "sslrootcert":
+		opts.SSLRootCert = value
+	case "connect_timeout":
+		timeout, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrap(err, "could not parse connect_timeout")
+		}
+		opts.ConnectTimeout = timeout
+	case "search_path":
+		// Search path of several schemas is passed as is
+		if schema, ok := parseIdentifier(value); ok {
+			opts.Schema = schema
+		} else {
+			setRuntimeParam(opts, key, value)
+		}
+	case "application_name":
+		opts.ApplicationName = value
+	case "statement_timeout":
+		return parseMillis(&opts.StatementTimeout, key, value)
+	case "lock_timeout":
+		return parseMillis(&opts.LockTimeout, key, value)
+	case "idle_in_transaction_session_timeout":
+		return parseMillis(&opts.IdleInTransactionSessionTimeout, key, value)
+	case "TimeZone", "timezone":
+		opts.TimeZone = value
+	default:
+		setRuntimeParam(opts, key, value)
+	}
+
+	return nil
+}
+
+// setRuntimeParam sets runtime parameter which has no dedicated option
+func setRuntimeParam(opts *Options, key, value string) {
+	if opts.RuntimeParams == nil {
+		opts.RuntimeParams = make(map[string]string)
+	}
+	opts.RuntimeParams[key] = value
+}
+
+// parseIdentifier parses single SQL identifier, quoted one is unescaped
+// and unquoted one is folded to lower case as the server does
+func parseIdentifier(value string) (string, bool) {
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		inner := value[1 : len(value)-1]
+		if strings.Contains(strings.Replace(inner, `""`, "", -1), `"`) {
+			return "", false
+		}
+		return strings.Replace(inner, `""`, `"`, -1), true
+	}
+
+	if value == "" || strings.ContainsAny(value, `",`) || strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+		return "", false
+	}
+
+	return strings.ToLower(value), true
+}
+
+// parseMillis parses timeout in milliseconds or in Go duration format
+func parseMillis(d *time.Duration, key, value string) error {
+	if millis, err := strconv.ParseInt(value, 10, 64); err == nil {
+		*d = time.Duration(millis) * time.Millisecond
+		return nil
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.Wrapf(err, "could not parse %s", key)
+	}
+	*d = parsed
+
+	return nil
+}
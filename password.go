@@ -0,0 +1,157 @@
+package pgx
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// resolvePassword returns password from options, password file or .pgpass file
+// Additional info: https://www.postgresql.org/docs/current/libpq-pgpass.html
+func (o *Options) resolvePassword() (string, error) {
+	if o.Password != "" {
+		return o.Password, nil
+	}
+
+	if o.PasswordFile != "" {
+		content, err := ioutil.ReadFile(o.PasswordFile)
+		if err != nil {
+			return "", errors.Wrap(err, "could not read password file")
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	}
+
+	passFile, explicit := o.passFilePath()
+	if passFile == "" {
+		return "", nil
+	}
+
+	password, err := lookupPassFile(passFile, o)
+	if err != nil && explicit {
+		return "", err
+	}
+
+	// Missing or insecure default .pgpass file is ignored as libpq does
+	return password, nil
+}
+
+// passFilePath returns path of .pgpass file and whether it is set explicitly
+func (o *Options) passFilePath() (string, bool) {
+	if o.PassFile != "" {
+		return o.PassFile, true
+	}
+	if path := os.Getenv("PGPASSFILE"); path != "" {
+		return path, false
+	}
+
+	u, err := user.Current()
+	if err != nil || u.HomeDir == "" {
+		return "", false
+	}
+
+	return filepath.Join(u.HomeDir, ".pgpass"), false
+}
+
+// lookupPassFile finds password of the first matching line in .pgpass file
+func lookupPassFile(path string, opts *Options) (password string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", errors.Wrap(err, "could not open .pgpass file")
+	}
+	defer func() {
+		e := file.Close()
+		if e != nil && err == nil {
+			err = errors.Wrap(e, "could not close .pgpass file")
+		}
+	}()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", errors.Wrap(err, "could not stat .pgpass file")
+	}
+	if err := checkPrivateFile(path, info); err != nil {
+		return "", err
+	}
+
+	host := opts.Host
+	if host == "" || strings.HasPrefix(host, "/") {
+		host = "localhost"
+	}
+	port := opts.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	dbName := opts.DBName
+	if dbName == "" {
+		dbName = opts.User
+	}
+	want := []string{host, strconv.FormatUint(uint64(port), 10), dbName, opts.User}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := splitPassFileLine(line)
+		if len(fields) != 5 {
+			continue
+		}
+		if matchPassFileFields(fields[:4], want) {
+			return fields[4], nil
+		}
+	}
+
+	return "", errors.Wrap(scanner.Err(), "could not read .pgpass file")
+}
+
+// splitPassFileLine splits .pgpass line by colons, \: and \\ are unescaped
+func splitPassFileLine(line string) []string {
+	var (
+		fields []string
+		field  strings.Builder
+	)
+
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+
+	return append(fields, field.String())
+}
+
+// matchPassFileFields checks .pgpass fields, * matches any value
+func matchPassFileFields(fields, want []string) bool {
+	for i, field := range fields {
+		if field != "*" && field != want[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkPrivateFile checks that file is not accessible by group or others as libpq demands
+func checkPrivateFile(path string, info os.FileInfo) error {
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return errors.Errorf("%s has group or world access, permissions should be u=rw (0600) or less", path)
+	}
+
+	return nil
+}
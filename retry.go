@@ -0,0 +1,121 @@
+package pgx
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultInitialBackoff is used when RetryPolicy.InitialBackoff is not set
+	defaultInitialBackoff = 100 * time.Millisecond
+	// maxBackoff limits the delay to keep it in time.Duration range
+	maxBackoff = math.MaxInt32 * time.Second
+)
+
+// RetryPolicy describes how operations failed with retriable errors are retried
+type RetryPolicy struct {
+	// The maximum number of attempts. Values less than 2 disable retries.
+	MaxAttempts int `yaml:"max_attempts" json:"max_attempts"`
+	// The delay before the first retry, it is doubled after every attempt.
+	// Default is 100ms.
+	InitialBackoff time.Duration `yaml:"initial_backoff" json:"initial_backoff"`
+	// The maximum delay between attempts. Zero means no limit.
+	MaxBackoff time.Duration `yaml:"max_backoff" json:"max_backoff"`
+	// The fraction of the delay which is randomized, from 0 to 1.
+	Jitter float64 `yaml:"jitter" json:"jitter"`
+}
+
+// WaitReady waits until database server accepts connections.
+// It retries with backoff of options retry policy until the context is done,
+// errors which are not retriable are returned immediately.
+// Server is ready when it rejects connection with not retriable error,
+// e.g. the role or its database does not exist.
+func WaitReady(ctx context.Context, opts *Options) error {
+	policy := opts.Retry
+	policy.MaxAttempts = math.MaxInt32
+
+	serverOpts := *opts
+	serverOpts.Retry = RetryPolicy{}
+
+	return retry(ctx, policy, func() error {
+		err := rootDo(ctx, serverOpts, func(db *sqlx.DB) error {
+			return nil
+		})
+		if _, ok := errors.Cause(err).(*pq.Error); ok && !IsRetriable(err) {
+			return nil
+		}
+		return err
+	})
+}
+
+// IsRetriable checks whether error is temporary and the operation could be retried,
+// e.g. connection is refused or the database system is starting up
+func IsRetriable(err error) bool {
+	switch cause := errors.Cause(err).(type) {
+	case nil:
+		return false
+	case *pq.Error:
+		switch cause.Code {
+		case "53300", // too_many_connections
+			"57P01", // admin_shutdown
+			"57P02", // crash_shutdown
+			"57P03": // cannot_connect_now
+			return true
+		}
+		// connection_exception class
+		return cause.Code.Class() == "08"
+	case net.Error:
+		return true
+	default:
+		return cause == io.EOF || cause == io.ErrUnexpectedEOF || cause == driver.ErrBadConn
+	}
+}
+
+// retry calls function until it succeeds, returns not retriable error,
+// attempts are exhausted or the context is done
+func retry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= policy.MaxAttempts || !IsRetriable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns delay after the given attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration { // nolint:gocritic
+	initial := p.InitialBackoff
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+
+	delay := float64(initial) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	if delay > float64(maxBackoff) {
+		delay = float64(maxBackoff)
+	}
+
+	jitter := math.Min(math.Max(p.Jitter, 0), 1)
+	delay -= delay * jitter * rand.Float64() // nolint:gosec
+
+	return time.Duration(delay)
+}
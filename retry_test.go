@@ -0,0 +1,153 @@
+package pgx
+
+import (
+	"context"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type WaitReadyPositiveSuite struct {
+	suite.Suite
+	options *Options
+}
+
+func (s *WaitReadyPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+}
+
+func (s *WaitReadyPositiveSuite) TestWaitReady() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	s.NoError(WaitReady(ctx, s.options))
+}
+
+func (s *WaitReadyPositiveSuite) TestWaitReadyRejected() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	opts := *s.options
+	opts.User = "pgx_unknown_role"
+	s.NoError(WaitReady(ctx, &opts), "Server rejecting connection is ready")
+}
+
+// Negative suite
+type WaitReadyNegativeSuite struct {
+	suite.Suite
+	options *Options
+}
+
+func (s *WaitReadyNegativeSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+	s.options.Port = 5435
+	s.options.Retry = RetryPolicy{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+}
+
+func (s *WaitReadyNegativeSuite) TestWaitReady() {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	s.Error(WaitReady(ctx, s.options))
+	s.True(time.Since(start) >= 300*time.Millisecond, "Must wait until context is done")
+}
+
+func (s *WaitReadyNegativeSuite) TestConnect() {
+	opts := *s.options
+	opts.Retry.MaxAttempts = 3
+
+	start := time.Now()
+	_, err := Connect(&opts)
+	s.Error(err)
+	s.True(time.Since(start) >= 30*time.Millisecond, "Must retry with backoff")
+}
+
+// Run tests
+func TestIsRetriable(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	assert.True(t, IsRetriable(refused), "Connection refused is retriable")
+	assert.True(t, IsRetriable(errors.Wrap(refused, "could not connect")), "Wrapped errors are unwrapped")
+	assert.True(t, IsRetriable(&pq.Error{Code: "57P03"}), "Database starting up is retriable")
+	assert.True(t, IsRetriable(&pq.Error{Code: "08006"}), "Connection failure is retriable")
+	assert.True(t, IsRetriable(io.ErrUnexpectedEOF), "Unexpected EOF is retriable")
+
+	assert.False(t, IsRetriable(nil), "Nil is not retriable")
+	assert.False(t, IsRetriable(&pq.Error{Code: "28P01"}), "Invalid password is not retriable")
+	assert.False(t, IsRetriable(errors.New("no migration")), "Generic errors are not retriable")
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{}
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1), "Must use default initial backoff")
+	assert.Equal(t, 400*time.Millisecond, policy.backoff(3), "Must double backoff")
+
+	policy = RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, 5*time.Second, policy.backoff(10), "Must limit backoff")
+	assert.Equal(t, 5*time.Second, policy.backoff(1000), "Must not overflow")
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		backoff := policy.backoff(2)
+		assert.True(t, backoff > time.Second && backoff <= 2*time.Second, "Must randomize backoff")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	attempts := 0
+	err := retry(context.Background(), policy, func() error {
+		attempts++
+		return refused
+	})
+	assert.Equal(t, refused, err, "Must return last error")
+	assert.Equal(t, 3, attempts, "Must exhaust attempts")
+
+	attempts = 0
+	err = retry(context.Background(), policy, func() error {
+		attempts++
+		if attempts < 2 {
+			return refused
+		}
+		return nil
+	})
+	assert.NoError(t, err, "Must succeed after retry")
+	assert.Equal(t, 2, attempts)
+
+	attempts = 0
+	err = retry(context.Background(), policy, func() error {
+		attempts++
+		return &pq.Error{Code: "28P01"}
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, attempts, "Must not retry not retriable errors")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	err = retry(ctx, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}, func() error {
+		attempts++
+		return refused
+	})
+	assert.Equal(t, refused, err)
+	assert.Equal(t, 1, attempts, "Must stop when context is done")
+}
+
+func TestWaitReadyPositiveSuite(t *testing.T) {
+	suite.Run(t, new(WaitReadyPositiveSuite))
+}
+
+func TestWaitReadyNegativeSuite(t *testing.T) {
+	suite.Run(t, new(WaitReadyNegativeSuite))
+}
@@ -0,0 +1,193 @@
+package pgx
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// Role is role parameters, omitted parameters are set to defaults on create
+// and left unchanged on alter
+// Additional info: https://www.postgresql.org/docs/current/sql-createrole.html
+type Role struct {
+	Name string
+	// Password is omitted when empty
+	Password string
+	// Login allows role to log in, nil omits the option. Default is NOLOGIN.
+	Login *bool
+	// How many concurrent connections the role can make, -1 means no limit.
+	// Nil omits the option. Default is no limit.
+	ConnectionLimit *int
+}
+
+// grantablePrivileges contains privileges which could be granted
+// Additional info: https://www.postgresql.org/docs/current/sql-grant.html
+var grantablePrivileges = map[string]bool{
+	"SELECT":         true,
+	"INSERT":         true,
+	"UPDATE":         true,
+	"DELETE":         true,
+	"TRUNCATE":       true,
+	"REFERENCES":     true,
+	"TRIGGER":        true,
+	"CREATE":         true,
+	"CONNECT":        true,
+	"TEMPORARY":      true,
+	"TEMP":           true,
+	"EXECUTE":        true,
+	"USAGE":          true,
+	"ALL":            true,
+	"ALL PRIVILEGES": true,
+}
+
+// CreateRole creates role by parameters
+func CreateRole(opts *Options, role *Role) error {
+	return CreateRoleContext(context.Background(), opts, role)
+}
+
+// CreateRoleContext creates role by parameters using the given context
+func CreateRoleContext(ctx context.Context, opts *Options, role *Role) error {
+	return rootDo(ctx, *opts, func(db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, "CREATE ROLE "+pq.QuoteIdentifier(role.Name)+buildRoleParams(role))
+		// Query is not included into error since it contains password
+		return errors.Wrapf(err, "could not create role %s", role.Name)
+	})
+}
+
+// AlterRole changes role parameters, omitted parameters are left unchanged
+func AlterRole(opts *Options, role *Role) error {
+	return AlterRoleContext(context.Background(), opts, role)
+}
+
+// AlterRoleContext changes role parameters using the given context,
+// omitted parameters are left unchanged
+func AlterRoleContext(ctx context.Context, opts *Options, role *Role) error {
+	return rootDo(ctx, *opts, func(db *sqlx.DB) error {
+		_, err := db.ExecContext(ctx, "ALTER ROLE "+pq.QuoteIdentifier(role.Name)+buildRoleParams(role))
+		// Query is not included into error since it contains password
+		return errors.Wrapf(err, "could not alter role %s", role.Name)
+	})
+}
+
+// DropRole drops role by name
+func DropRole(opts *Options, name string) error {
+	return DropRoleContext(context.Background(), opts, name)
+}
+
+// DropRoleContext drops role by name using the given context
+func DropRoleContext(ctx context.Context, opts *Options, name string) error {
+	return rootExec(ctx, *opts, "DROP ROLE "+pq.QuoteIdentifier(name))
+}
+
+// GrantDatabase grants privileges on the database from options to the role
+func GrantDatabase(opts *Options, role string, privileges ...string) error {
+	return GrantDatabaseContext(context.Background(), opts, role, privileges...)
+}
+
+// GrantDatabaseContext grants privileges on the database from options to the role using the given context
+func GrantDatabaseContext(ctx context.Context, opts *Options, role string, privileges ...string) error {
+	query, err := buildGrantQuery(privileges, "DATABASE "+pq.QuoteIdentifier(opts.DBName), role)
+	if err != nil {
+		return err
+	}
+
+	return rootExec(ctx, *opts, query)
+}
+
+// GrantSchema grants privileges on the schema to the role
+func GrantSchema(opts *Options, schema, role string, privileges ...string) error {
+	return GrantSchemaContext(context.Background(), opts, schema, role, privileges...)
+}
+
+// GrantSchemaContext grants privileges on the schema to the role using the given context
+func GrantSchemaContext(ctx context.Context, opts *Options, schema, role string, privileges ...string) error {
+	query, err := buildGrantQuery(privileges, "SCHEMA "+pq.QuoteIdentifier(schema), role)
+	if err != nil {
+		return err
+	}
+
+	return dbExec(ctx, *opts, query)
+}
+
+// GrantTables grants privileges on all existing tables in the schema to the role
+func GrantTables(opts *Options, schema, role string, privileges ...string) error {
+	return GrantTablesContext(context.Background(), opts, schema, role, privileges...)
+}
+
+// GrantTablesContext grants privileges on all existing tables in the schema to the role using the given context
+func GrantTablesContext(ctx context.Context, opts *Options, schema, role string, privileges ...string) error {
+	query, err := buildGrantQuery(privileges, "ALL TABLES IN SCHEMA "+pq.QuoteIdentifier(schema), role)
+	if err != nil {
+		return err
+	}
+
+	return dbExec(ctx, *opts, query)
+}
+
+// GrantDefaultPrivileges grants privileges on tables created in the schema
+// by the user from options in the future to the role
+func GrantDefaultPrivileges(opts *Options, schema, role string, privileges ...string) error {
+	return GrantDefaultPrivilegesContext(context.Background(), opts, schema, role, privileges...)
+}
+
+// GrantDefaultPrivilegesContext grants privileges on tables created in the schema
+// by the user from options in the future to the role using the given context
+func GrantDefaultPrivilegesContext(
+	ctx context.Context, opts *Options, schema, role string, privileges ...string,
+) error {
+	query, err := buildGrantQuery(privileges, "TABLES", role)
+	if err != nil {
+		return err
+	}
+
+	return dbExec(ctx, *opts, "ALTER DEFAULT PRIVILEGES IN SCHEMA "+pq.QuoteIdentifier(schema)+" "+query)
+}
+
+// buildRoleParams builds parameters part of CREATE ROLE and ALTER ROLE statements,
+// only the given parameters are included
+func buildRoleParams(role *Role) string {
+	var params []string
+	if role.Login != nil {
+		if *role.Login {
+			params = append(params, "LOGIN")
+		} else {
+			params = append(params, "NOLOGIN")
+		}
+	}
+	if role.Password != "" {
+		params = append(params, "PASSWORD "+pq.QuoteLiteral(role.Password))
+	}
+	if role.ConnectionLimit != nil {
+		params = append(params, fmt.Sprintf("CONNECTION LIMIT %d", *role.ConnectionLimit))
+	}
+
+	if len(params) == 0 {
+		return ""
+	}
+
+	return " WITH " + strings.Join(params, " ")
+}
+
+// buildGrantQuery builds GRANT statement, privileges are validated since they could not be quoted
+func buildGrantQuery(privileges []string, object, role string) (string, error) {
+	if len(privileges) == 0 {
+		return "", errors.New("no privileges to grant")
+	}
+
+	normalized := make([]string, 0, len(privileges))
+	for _, priv := range privileges {
+		priv = strings.ToUpper(strings.TrimSpace(priv))
+		if !grantablePrivileges[priv] {
+			return "", errors.Errorf("unknown privilege %q", priv)
+		}
+		normalized = append(normalized, priv)
+	}
+
+	return fmt.Sprintf(
+		"GRANT %s ON %s TO %s", strings.Join(normalized, ", "), object, pq.QuoteIdentifier(role),
+	), nil
+}
@@ -0,0 +1,162 @@
+package pgx
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type RolePositiveSuite struct {
+	suite.Suite
+	options     *Options
+	role        *Role
+	roleOptions *Options
+}
+
+func (s *RolePositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+	login, limit := true, 2
+	s.role = &Role{
+		Name:            "pgx_test_role",
+		Password:        "pgx_test_password",
+		Login:           &login,
+		ConnectionLimit: &limit,
+	}
+
+	roleOptions := *s.options
+	roleOptions.User = s.role.Name
+	roleOptions.Password = s.role.Password
+	s.roleOptions = &roleOptions
+}
+
+func (s *RolePositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(rootExec(
+		context.Background(), *s.options, "DROP ROLE IF EXISTS "+pq.QuoteIdentifier(s.role.Name),
+	))
+	s.Require().NoError(Create(s.options))
+	s.Require().NoError(CreateRole(s.options, s.role))
+
+	db, err := Connect(s.options)
+	s.Require().NoError(err)
+	_, err = db.Exec("CREATE TABLE samples (id serial PRIMARY KEY)")
+	s.Require().NoError(err)
+	s.Require().NoError(db.Close())
+}
+
+func (s *RolePositiveSuite) TearDownTest() {
+	s.Require().NoError(DropForce(s.options))
+	s.Require().NoError(DropRole(s.options, s.role.Name))
+}
+
+func (s *RolePositiveSuite) TestCreateRole() {
+	db, err := Connect(s.roleOptions)
+	s.Require().NoError(err)
+	s.NoError(db.Close())
+}
+
+func (s *RolePositiveSuite) TestAlterRole() {
+	login := false
+	s.NoError(AlterRole(s.options, &Role{Name: s.role.Name, Login: &login}))
+
+	_, err := Connect(s.roleOptions)
+	s.Error(err)
+}
+
+func (s *RolePositiveSuite) TestAlterRolePassword() {
+	s.NoError(AlterRole(s.options, &Role{Name: s.role.Name, Password: "pgx_new_password"}))
+
+	_, err := Connect(s.roleOptions)
+	s.Error(err, "Old password must be rejected")
+
+	roleOptions := *s.roleOptions
+	roleOptions.Password = "pgx_new_password"
+	db, err := Connect(&roleOptions)
+	s.Require().NoError(err, "Role must keep login")
+	s.NoError(db.Close())
+}
+
+func (s *RolePositiveSuite) TestGrantDatabase() {
+	s.Require().NoError(rootExec(
+		context.Background(), *s.options,
+		"REVOKE CONNECT ON DATABASE "+pq.QuoteIdentifier(s.options.DBName)+" FROM PUBLIC",
+	))
+	_, err := Connect(s.roleOptions)
+	s.Require().Error(err)
+
+	s.NoError(GrantDatabase(s.options, s.role.Name, "connect"))
+
+	db, err := Connect(s.roleOptions)
+	s.Require().NoError(err)
+	s.NoError(db.Close())
+}
+
+func (s *RolePositiveSuite) TestGrantTables() {
+	s.NoError(GrantSchema(s.options, "public", s.role.Name, "USAGE"))
+	s.NoError(GrantTables(s.options, "public", s.role.Name, "SELECT", "INSERT"))
+
+	db, err := Connect(s.roleOptions)
+	s.Require().NoError(err)
+	defer db.Close() // nolint:errcheck
+
+	_, err = db.Exec("INSERT INTO samples DEFAULT VALUES")
+	s.Error(err, "Sequence usage is not granted")
+	assertRowsCount(s.T(), db, "samples", 0)
+}
+
+func (s *RolePositiveSuite) TestGrantDefaultPrivileges() {
+	s.NoError(GrantDefaultPrivileges(s.options, "public", s.role.Name, "SELECT"))
+
+	db, err := Connect(s.options)
+	s.Require().NoError(err)
+	_, err = db.Exec("CREATE TABLE users (id serial PRIMARY KEY)")
+	s.Require().NoError(err)
+	s.Require().NoError(db.Close())
+
+	roleDB, err := Connect(s.roleOptions)
+	s.Require().NoError(err)
+	defer roleDB.Close() // nolint:errcheck
+
+	assertRowsCount(s.T(), roleDB, "users", 0)
+}
+
+func (s *RolePositiveSuite) TestGrantUnknownPrivilege() {
+	s.Error(GrantSchema(s.options, "public", s.role.Name, "USAGE; DROP TABLE samples"))
+}
+
+// Run tests
+func TestBuildRoleParams(t *testing.T) {
+	role := &Role{Name: "app"}
+	assert.Equal(t, "", buildRoleParams(role), "Must omit empty parameters")
+
+	login, limit := false, 0
+	role = &Role{Name: "app", Login: &login, ConnectionLimit: &limit}
+	assert.Equal(t, " WITH NOLOGIN CONNECTION LIMIT 0", buildRoleParams(role), "Must include zero parameters")
+
+	login, limit = true, 10
+	role = &Role{Name: "app", Password: "it's secret", Login: &login, ConnectionLimit: &limit}
+	assert.Equal(
+		t, " WITH LOGIN PASSWORD 'it''s secret' CONNECTION LIMIT 10", buildRoleParams(role),
+		"Must return quoted parameters",
+	)
+}
+
+func TestBuildGrantQuery(t *testing.T) {
+	query, err := buildGrantQuery([]string{"select", " Insert "}, `SCHEMA "public"`, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, `GRANT SELECT, INSERT ON SCHEMA "public" TO "app"`, query, "Must return normalized query")
+
+	_, err = buildGrantQuery(nil, `SCHEMA "public"`, "app")
+	assert.Error(t, err, "Must require privileges")
+
+	_, err = buildGrantQuery([]string{"SELECT; DROP TABLE users"}, `SCHEMA "public"`, "app")
+	assert.Error(t, err, "Must reject unknown privileges")
+}
+
+func TestRolePositiveSuite(t *testing.T) {
+	suite.Run(t, new(RolePositiveSuite))
+}
@@ -0,0 +1,27 @@
+package pgx
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+)
+
+// CreateSchema creates schema in the database
+func CreateSchema(opts *Options, schema string) error {
+	return CreateSchemaContext(context.Background(), opts, schema)
+}
+
+// CreateSchemaContext creates schema in the database using the given context
+func CreateSchemaContext(ctx context.Context, opts *Options, schema string) error {
+	return dbExec(ctx, *opts, "CREATE SCHEMA "+pq.QuoteIdentifier(schema))
+}
+
+// DropSchema drops schema with all its objects from the database
+func DropSchema(opts *Options, schema string) error {
+	return DropSchemaContext(context.Background(), opts, schema)
+}
+
+// DropSchemaContext drops schema with all its objects from the database using the given context
+func DropSchemaContext(ctx context.Context, opts *Options, schema string) error {
+	return dbExec(ctx, *opts, "DROP SCHEMA "+pq.QuoteIdentifier(schema)+" CASCADE")
+}
@@ -0,0 +1,97 @@
+package pgx
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type SchemaPositiveSuite struct {
+	suite.Suite
+	options        *Options
+	schemaOptions  *Options
+	migrationsPath string
+	seedsPath      string
+	db             *sqlx.DB
+}
+
+func (s *SchemaPositiveSuite) SetupSuite() {
+	s.setup("app")
+}
+
+func (s *SchemaPositiveSuite) setup(schema string) {
+	s.options = buildTestOptions(s.T())
+	s.migrationsPath = "testdata/migrations"
+	s.seedsPath = "testdata/seeds"
+
+	schemaOptions := *s.options
+	schemaOptions.Schema = schema
+	s.schemaOptions = &schemaOptions
+}
+
+func (s *SchemaPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+	s.Require().NoError(CreateSchema(s.options, s.schemaOptions.Schema))
+
+	db, err := Connect(s.schemaOptions)
+	s.Require().NoError(err)
+	s.db = db
+}
+
+func (s *SchemaPositiveSuite) TearDownTest() {
+	if s.db != nil {
+		s.Require().NoError(s.db.Close())
+	}
+	s.Require().NoError(Drop(s.options))
+}
+
+func (s *SchemaPositiveSuite) TestMigrateUp() {
+	s.NoError(MigrateUp(s.schemaOptions, s.migrationsPath))
+	s.NoError(SeedUp(s.schemaOptions, s.seedsPath))
+
+	assertMigrationsVersion(s.T(), s.db, 2)
+	assertSeedsVersion(s.T(), s.db, 2)
+	assertRowsCount(s.T(), s.db, "samples", 1)
+
+	db, err := Connect(s.options)
+	s.Require().NoError(err)
+	defer db.Close() // nolint:errcheck
+
+	assertTableNotExist(s.T(), db, "schema_migrations")
+	assertTableNotExist(s.T(), db, "schema_seeds")
+	assertTableNotExist(s.T(), db, "samples")
+}
+
+func (s *SchemaPositiveSuite) TestDropSchema() {
+	s.Require().NoError(MigrateUp(s.schemaOptions, s.migrationsPath))
+
+	s.NoError(DropSchema(s.options, s.schemaOptions.Schema))
+
+	assertTableNotExist(s.T(), s.db, "schema_migrations")
+	assertTableNotExist(s.T(), s.db, "samples")
+}
+
+func (s *SchemaPositiveSuite) TestCreateSchemaTwice() {
+	s.Error(CreateSchema(s.options, s.schemaOptions.Schema))
+}
+
+// Mixed case suite
+type SchemaMixedCaseSuite struct {
+	SchemaPositiveSuite
+}
+
+func (s *SchemaMixedCaseSuite) SetupSuite() {
+	s.setup("MyApp")
+}
+
+// Run tests
+func TestSchemaPositiveSuite(t *testing.T) {
+	suite.Run(t, new(SchemaPositiveSuite))
+}
+
+func TestSchemaMixedCaseSuite(t *testing.T) {
+	suite.Run(t, new(SchemaMixedCaseSuite))
+}
@@ -1,16 +1,38 @@
 package pgx
 
+import (
+	"context"
+)
+
+// seedsTable is the name of the table storing seeds version
+const seedsTable = "schema_seeds"
+
+// SeedUp runs seeds on the given database
 func SeedUp(opts *Options, seedsPath string) error {
-	dbURL := BuildURL(opts) + "&x-migrations-table=schema_seeds"
-	return migrateUp(seedsPath, dbURL)
+	return SeedUpContext(context.Background(), opts, seedsPath)
+}
+
+// SeedUpContext runs seeds on the given database using the given context
+func SeedUpContext(ctx context.Context, opts *Options, seedsPath string) error {
+	return migrateUp(ctx, opts, seedsPath, seedsTable)
 }
 
+// SeedDown rollbacks seeds on the given database
 func SeedDown(opts *Options, seedsPath string) error {
-	dbURL := BuildURL(opts) + "&x-migrations-table=schema_seeds"
-	return migrateDown(seedsPath, dbURL)
+	return SeedDownContext(context.Background(), opts, seedsPath)
 }
 
+// SeedDownContext rollbacks seeds on the given database using the given context
+func SeedDownContext(ctx context.Context, opts *Options, seedsPath string) error {
+	return migrateDown(ctx, opts, seedsPath, seedsTable)
+}
+
+// SeedTo runs seeds up to the given version on the given database
 func SeedTo(opts *Options, seedsPath string, version uint) error {
-	dbURL := BuildURL(opts) + "&x-migrations-table=schema_seeds"
-	return migrateTo(seedsPath, dbURL, version)
+	return SeedToContext(context.Background(), opts, seedsPath, version)
+}
+
+// SeedToContext runs seeds up to the given version on the given database using the given context
+func SeedToContext(ctx context.Context, opts *Options, seedsPath string, version uint) error {
+	return migrateTo(ctx, opts, seedsPath, seedsTable, version)
 }
@@ -1,6 +1,7 @@
 package pgx
 
 import (
+	"context"
 	"testing"
 
 	"github.com/jmoiron/sqlx"
@@ -27,7 +28,7 @@ func (s *SeedPositiveSuite) SetupSuite() {
 }
 
 func (s *SeedPositiveSuite) SetupTest() {
-	Drop(s.options) // nolint:errcheck
+	s.Require().NoError(DropIfExists(s.options))
 
 	s.Require().NoError(Create(s.options))
 	s.Require().NoError(MigrateUp(s.options, s.migrationsPath))
@@ -80,6 +81,17 @@ func (s *SeedPositiveSuite) TestSeedToDown() {
 	assertRowsCount(s.T(), s.db, "users", 0)
 }
 
+func (s *SeedPositiveSuite) TestSeedUpContextCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s.Error(SeedUpContext(ctx, s.options, s.seedsPath))
+
+	assertRowsCount(s.T(), s.db, "samples", 0)
+	assertRowsCount(s.T(), s.db, "users", 0)
+	s.NoError(SeedUp(s.options, s.seedsPath))
+}
+
 // Negative suite with broken migrations
 type SeedBrokenSeedsSuite struct {
 	SeedPositiveSuite
@@ -0,0 +1,7 @@
+BEGIN;
+  CREATE TABLE IF NOT EXISTS samples (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL
+  );
+  SELECT pg_sleep(60); -- Hang migration for purpose
+COMMIT;
@@ -0,0 +1,175 @@
+package pgx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// sslRequestCode is the code of SSLRequest message
+// Additional info: https://www.postgresql.org/docs/current/protocol-message-formats.html
+const sslRequestCode = 80877103
+
+// startTLS requests SSL encryption and performs TLS handshake
+// Additional info: https://www.postgresql.org/docs/current/protocol-flow.html#id-1.10.5.7.11
+func (d *connDialer) startTLS(ctx context.Context, conn net.Conn) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, errors.Wrap(err, "could not set deadline")
+		}
+		// The deadline limits only SSL negotiation, pq does not reset it without connect_timeout,
+		// so pooled connection would fail once it passes
+		defer conn.SetDeadline(time.Time{}) // nolint:errcheck
+	}
+
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], sslRequestCode)
+	if _, err := conn.Write(request); err != nil {
+		return nil, errors.Wrap(err, "could not send SSL request")
+	}
+
+	response := make([]byte, 1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		return nil, errors.Wrap(err, "could not read SSL response")
+	}
+
+	switch response[0] {
+	case 'S':
+		tlsConn := tls.Client(conn, d.tls)
+		if err := tlsConn.Handshake(); err != nil {
+			return nil, errors.Wrap(err, "could not establish TLS connection")
+		}
+		return tlsConn, nil
+	case 'N':
+		if d.required {
+			return nil, errors.New("server does not support SSL, but SSL is required")
+		}
+		return conn, nil
+	default:
+		return nil, errors.Errorf("unexpected response %q to SSL request", response[0])
+	}
+}
+
+// hasCustomTLS checks whether TLS is configured beyond certificate files which pq supports
+func (o *Options) hasCustomTLS() bool {
+	return o.TLSConfig != nil || o.SSLCertPEM != "" || o.SSLKeyPEM != "" || o.SSLRootCertPEM != ""
+}
+
+// buildTLSConfig builds TLS configuration of the host from options,
+// PEM content takes precedence over files, verification follows sslmode as libpq does
+// unless TLSConfig is set.
+// Additional info: https://www.postgresql.org/docs/current/libpq-ssl.html#LIBPQ-SSL-PROTECTION
+func buildTLSConfig(opts *Options) (*tls.Config, error) {
+	config := &tls.Config{}
+	if opts.TLSConfig != nil {
+		config = opts.TLSConfig.Clone()
+	}
+
+	rootPEM, err := readPEM(opts.SSLRootCertPEM, opts.SSLRootCert, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read root certificate")
+	}
+	if rootPEM != nil {
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(rootPEM) {
+			return nil, errors.New("could not parse root certificate")
+		}
+	}
+
+	certPEM, err := readPEM(opts.SSLCertPEM, opts.SSLCert, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read certificate")
+	}
+	keyPEM, err := readPEM(opts.SSLKeyPEM, opts.SSLKey, true)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read key")
+	}
+	if certPEM != nil || keyPEM != nil {
+		// Key is not included into error since it is secret
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, errors.New("could not load certificate and key pair")
+		}
+		config.Certificates = append(config.Certificates, cert)
+	}
+
+	if opts.TLSConfig != nil {
+		if config.ServerName == "" && !config.InsecureSkipVerify {
+			config.ServerName = opts.Host
+		}
+		return config, nil
+	}
+
+	switch opts.SSLMode {
+	case "verify-full":
+		config.ServerName = opts.Host
+	case "verify-ca":
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyCA(config.RootCAs)
+	default:
+		config.InsecureSkipVerify = true
+		// Root certificate makes require mode behave like verify-ca for backwards compatibility
+		if rootPEM != nil {
+			config.VerifyPeerCertificate = verifyCA(config.RootCAs)
+		}
+	}
+
+	return config, nil
+}
+
+// readPEM returns PEM content or reads it from the file, nil is returned when both are empty
+func readPEM(content, path string, private bool) ([]byte, error) {
+	if content != "" {
+		return []byte(content), nil
+	}
+	if path == "" {
+		return nil, nil
+	}
+
+	if private {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkPrivateFile(path, info); err != nil {
+			return nil, err
+		}
+	}
+
+	return ioutil.ReadFile(path)
+}
+
+// verifyCA returns function which verifies server certificate chain without host name check
+func verifyCA(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("server did not provide certificate")
+		}
+
+		certs := make([]*x509.Certificate, 0, len(rawCerts))
+		for _, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return errors.Wrap(err, "could not parse server certificate")
+			}
+			certs = append(certs, cert)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+
+		_, err := certs[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+		return errors.Wrap(err, "could not verify server certificate")
+	}
+}
@@ -0,0 +1,176 @@
+package pgx
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// generateCert generates self-signed certificate for the host and returns certificate and key PEM
+func generateCert(t *testing.T, host string) (certPEM, keyPEM string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+// serveSSLRequest accepts one connection, answers SSL request and performs TLS handshake
+func serveSSLRequest(t *testing.T, listener net.Listener, response byte, cert tls.Certificate) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close() // nolint:errcheck
+
+		request := make([]byte, 8)
+		if _, err = io.ReadFull(conn, request); err != nil {
+			done <- err
+			return
+		}
+		if _, err = conn.Write([]byte{response}); err != nil || response != 'S' {
+			done <- err
+			return
+		}
+
+		done <- tls.Server(conn, &tls.Config{Certificates: []tls.Certificate{cert}}).Handshake()
+	}()
+
+	return done
+}
+
+func TestTLSDialer(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, "db.local")
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	require.NoError(t, err)
+
+	insecure := &tls.Config{InsecureSkipVerify: true} // nolint:gosec
+	cases := []struct {
+		name     string
+		opts     *Options
+		response byte
+		ok       bool
+		deadline time.Duration
+	}{
+		{"verify-full", &Options{Host: "db.local", SSLMode: "verify-full", SSLRootCertPEM: certPEM}, 'S', true, 0},
+		{"wrong host", &Options{Host: "other.local", SSLMode: "verify-full", SSLRootCertPEM: certPEM}, 'S', false, 0},
+		{"verify-ca", &Options{Host: "other.local", SSLMode: "verify-ca", SSLRootCertPEM: certPEM}, 'S', true, 0},
+		{"unknown root", &Options{Host: "db.local", SSLMode: "verify-full"}, 'S', false, 0},
+		{"tls config", &Options{TLSConfig: insecure}, 'S', true, 0},
+		{"required", &Options{SSLMode: "require", SSLRootCertPEM: certPEM}, 'N', false, 0},
+		{"prefer", &Options{SSLMode: "prefer", SSLRootCertPEM: certPEM}, 'N', true, 0},
+		{"deadline", &Options{TLSConfig: insecure}, 'S', true, 50 * time.Millisecond},
+		{"deadline without SSL", &Options{SSLMode: "prefer", TLSConfig: insecure}, 'N', true, 50 * time.Millisecond},
+	}
+
+	for _, c := range cases {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		done := serveSSLRequest(t, listener, c.response, cert)
+
+		config, err := buildTLSConfig(c.opts)
+		require.NoError(t, err, c.name)
+		timeout := time.Second
+		if c.deadline > 0 {
+			timeout = c.deadline
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		dialer := &connDialer{
+			dialer:   &netDialer{},
+			tls:      config,
+			required: c.opts.SSLMode != "prefer",
+		}
+
+		conn, err := dialer.DialContext(ctx, "tcp", listener.Addr().String())
+		if c.ok {
+			require.NoError(t, err, c.name)
+			assert.NoError(t, <-done, c.name)
+			if c.deadline > 0 {
+				// Server has closed the connection, so reading fails with EOF unless the deadline is left
+				time.Sleep(2 * c.deadline)
+				_, err = conn.Read(make([]byte, 1))
+				netErr, ok := err.(net.Error)
+				assert.False(t, ok && netErr.Timeout(), "%s: deadline must be cleared", c.name)
+			}
+			assert.NoError(t, conn.Close(), c.name)
+		} else {
+			assert.Error(t, err, c.name)
+		}
+
+		cancel()
+		assert.NoError(t, listener.Close())
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, "db.local")
+
+	config, err := buildTLSConfig(&Options{Host: "db.local", SSLCertPEM: certPEM, SSLKeyPEM: keyPEM})
+	require.NoError(t, err)
+	assert.Len(t, config.Certificates, 1, "Must load client certificate")
+	assert.True(t, config.InsecureSkipVerify, "Must not verify server in require mode without root certificate")
+
+	base := &tls.Config{ServerName: "primary.local"}
+	config, err = buildTLSConfig(&Options{Host: "db.local", TLSConfig: base, SSLRootCertPEM: certPEM})
+	require.NoError(t, err)
+	assert.Equal(t, "primary.local", config.ServerName, "Must keep TLS config settings")
+	assert.NotNil(t, config.RootCAs, "Must add root certificate")
+	assert.Nil(t, base.RootCAs, "Must not modify TLS config")
+
+	_, err = buildTLSConfig(&Options{SSLCertPEM: certPEM, SSLKeyPEM: "secret"})
+	require.Error(t, err, "Must reject invalid key")
+	assert.NotContains(t, err.Error(), "secret", "Must not expose key")
+
+	_, err = buildTLSConfig(&Options{SSLRootCertPEM: "garbage"})
+	assert.Error(t, err, "Must reject invalid root certificate")
+}
+
+func TestValidatePEM(t *testing.T) {
+	certPEM, keyPEM := generateCert(t, "db.local")
+
+	opts := &Options{Host: "localhost", Port: 5432, SSLCertPEM: certPEM, SSLKeyPEM: keyPEM, SSLRootCertPEM: certPEM}
+	assert.NoError(t, opts.Validate(), "Must accept valid PEM")
+
+	opts = &Options{Host: "localhost", Port: 5432, SSLCertPEM: certPEM, SSLRootCertPEM: "garbage"}
+	err := opts.Validate()
+	require.Error(t, err)
+
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok, "Must return *ValidationError")
+	assert.Len(t, validationErr.Field("SSLRootCertPEM"), 1, "Must reject invalid root certificate")
+	assert.Len(t, validationErr.Field("SSLKeyPEM"), 1, "Must require key with certificate")
+}
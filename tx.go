@@ -0,0 +1,136 @@
+package pgx
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+)
+
+// PostgreSQL error codes of transactions which could be retried
+// Additional info: https://www.postgresql.org/docs/current/mvcc-serialization-failure-handling.html
+const (
+	errCodeSerializationFailure pq.ErrorCode = "40001"
+	errCodeDeadlockDetected     pq.ErrorCode = "40P01"
+)
+
+// defaultTxMaxAttempts is used when TxOptions.Retry.MaxAttempts is not set
+const defaultTxMaxAttempts = 3
+
+// savepointID makes savepoint names unique
+var savepointID uint64
+
+// TxOptions is transaction parameters
+type TxOptions struct {
+	// Isolation level, default is the server default, usually read committed.
+	Isolation sql.IsolationLevel
+	// ReadOnly starts read-only transaction.
+	ReadOnly bool
+	// Retry policy of transactions failed with serialization failure or deadlock.
+	// Default is 3 attempts, MaxAttempts of 1 disables retries.
+	Retry RetryPolicy
+}
+
+// WithTx runs function in transaction, it is committed when function succeeds
+// and rolled back when function returns error or panics.
+// Whole transaction is retried with backoff when it fails with serialization failure or deadlock,
+// so the function must be safe to call several times.
+//
+// The db is *sqlx.DB, e.g. Cluster.Primary, or *sqlx.Tx of the outer transaction.
+// Nested call inside the outer transaction runs function in savepoint, which is released on success
+// and rolled back to on error or panic. Options are ignored for nested calls, the outer transaction
+// is retried instead.
+func WithTx(ctx context.Context, db sqlx.ExtContext, opts *TxOptions, fn func(*sqlx.Tx) error) error {
+	if opts == nil {
+		opts = &TxOptions{}
+	}
+
+	var pool *sqlx.DB
+	switch db := db.(type) {
+	case *sqlx.Tx:
+		return runSavepoint(ctx, db, fn)
+	case *sqlx.DB:
+		pool = db
+	default:
+		return errors.Errorf("could not begin transaction on %T", db)
+	}
+
+	policy := opts.Retry
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaultTxMaxAttempts
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, pool, opts, fn)
+		if err == nil || attempt >= policy.MaxAttempts || !IsSerializationFailure(err) {
+			return err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// IsSerializationFailure checks whether transaction failed with serialization failure or deadlock
+// and could be retried
+func IsSerializationFailure(err error) bool {
+	return hasErrorCode(err, errCodeSerializationFailure, errCodeDeadlockDetected)
+}
+
+// runTx runs function in single transaction
+func runTx(ctx context.Context, db *sqlx.DB, opts *TxOptions, fn func(*sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: opts.Isolation, ReadOnly: opts.ReadOnly})
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback() // nolint:errcheck
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		// Rollback error is less important than the cause of rollback
+		tx.Rollback() // nolint:errcheck
+		return err
+	}
+
+	return errors.Wrap(tx.Commit(), "could not commit transaction")
+}
+
+// runSavepoint runs function in savepoint of the transaction
+// Additional info: https://www.postgresql.org/docs/current/sql-savepoint.html
+func runSavepoint(ctx context.Context, tx *sqlx.Tx, fn func(*sqlx.Tx) error) error {
+	name := pq.QuoteIdentifier(fmt.Sprintf("pgx_savepoint_%d", atomic.AddUint64(&savepointID, 1)))
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return errors.Wrap(err, "could not create savepoint")
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name) // nolint:errcheck
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		// Rollback error is less important than the cause of rollback
+		tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name) // nolint:errcheck
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return errors.Wrap(err, "could not release savepoint")
+}
@@ -0,0 +1,201 @@
+package pgx
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+// Positive suite
+type TxPositiveSuite struct {
+	suite.Suite
+	options *Options
+	db      *sqlx.DB
+}
+
+func (s *TxPositiveSuite) SetupSuite() {
+	s.options = buildTestOptions(s.T())
+}
+
+func (s *TxPositiveSuite) SetupTest() {
+	s.Require().NoError(DropIfExists(s.options))
+	s.Require().NoError(Create(s.options))
+
+	var err error
+	s.db, err = Connect(s.options)
+	s.Require().NoError(err)
+
+	_, err = s.db.Exec("CREATE TABLE users (id INTEGER)")
+	s.Require().NoError(err)
+}
+
+func (s *TxPositiveSuite) TearDownTest() {
+	s.Require().NoError(s.db.Close())
+	s.Require().NoError(Drop(s.options))
+}
+
+func (s *TxPositiveSuite) TestCommit() {
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		_, err := tx.Exec("INSERT INTO users VALUES (1)")
+		return err
+	})
+	s.NoError(err)
+	assertRowsCount(s.T(), s.db, "users", 1)
+}
+
+func (s *TxPositiveSuite) TestRollback() {
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("INSERT INTO users VALUES (1)"); err != nil {
+			return err
+		}
+		return errors.New("failed")
+	})
+	s.EqualError(err, "failed")
+	assertRowsCount(s.T(), s.db, "users", 0)
+}
+
+func (s *TxPositiveSuite) TestRollbackOnPanic() {
+	s.PanicsWithValue("failed", func() {
+		WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error { // nolint:errcheck
+			if _, err := tx.Exec("INSERT INTO users VALUES (1)"); err != nil {
+				return err
+			}
+			panic("failed")
+		})
+	})
+	assertRowsCount(s.T(), s.db, "users", 0)
+}
+
+func (s *TxPositiveSuite) TestOptions() {
+	opts := &TxOptions{Isolation: sql.LevelSerializable, ReadOnly: true}
+	err := WithTx(context.Background(), s.db, opts, func(tx *sqlx.Tx) error {
+		var isolation string
+		if err := tx.Get(&isolation, "SHOW transaction_isolation"); err != nil {
+			return err
+		}
+		s.Equal("serializable", isolation)
+
+		_, err := tx.Exec("INSERT INTO users VALUES (1)")
+		return err
+	})
+	s.Error(err, "Read-only transaction must reject writes")
+}
+
+func (s *TxPositiveSuite) TestRetry() {
+	attempts := 0
+	opts := &TxOptions{Retry: RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}}
+	err := WithTx(context.Background(), s.db, opts, func(tx *sqlx.Tx) error {
+		attempts++
+		if _, err := tx.Exec("INSERT INTO users VALUES ($1)", attempts); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return errors.Wrap(&pq.Error{Code: "40001"}, "conflict")
+		}
+		return nil
+	})
+	s.NoError(err)
+	s.Equal(3, attempts)
+	assertRowsCount(s.T(), s.db, "users", 1)
+}
+
+func (s *TxPositiveSuite) TestRetryDefault() {
+	attempts := 0
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		attempts++
+		return &pq.Error{Code: "40001"}
+	})
+	s.True(IsSerializationFailure(err))
+	s.Equal(defaultTxMaxAttempts, attempts, "Must retry by default")
+
+	attempts = 0
+	err = WithTx(context.Background(), s.db, &TxOptions{Retry: RetryPolicy{MaxAttempts: 1}}, func(tx *sqlx.Tx) error {
+		attempts++
+		return &pq.Error{Code: "40001"}
+	})
+	s.True(IsSerializationFailure(err))
+	s.Equal(1, attempts, "Must not retry when retries are disabled")
+}
+
+func (s *TxPositiveSuite) TestRetryExhausted() {
+	attempts := 0
+	opts := &TxOptions{Retry: RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}}
+	err := WithTx(context.Background(), s.db, opts, func(tx *sqlx.Tx) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	s.True(IsSerializationFailure(err))
+	s.Equal(2, attempts)
+}
+
+func (s *TxPositiveSuite) TestNested() {
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		if _, err := tx.Exec("INSERT INTO users VALUES (1)"); err != nil {
+			return err
+		}
+
+		err := WithTx(context.Background(), tx, nil, func(nested *sqlx.Tx) error {
+			if _, err := nested.Exec("INSERT INTO users VALUES (2)"); err != nil {
+				return err
+			}
+			return errors.New("failed")
+		})
+		s.EqualError(err, "failed")
+
+		s.PanicsWithValue("failed", func() {
+			WithTx(context.Background(), tx, nil, func(nested *sqlx.Tx) error { // nolint:errcheck
+				if _, err := nested.Exec("INSERT INTO users VALUES (3)"); err != nil {
+					return err
+				}
+				panic("failed")
+			})
+		})
+
+		return WithTx(context.Background(), tx, nil, func(nested *sqlx.Tx) error {
+			return WithTx(context.Background(), nested, nil, func(deep *sqlx.Tx) error {
+				_, err := deep.Exec("INSERT INTO users VALUES (4)")
+				return err
+			})
+		})
+	})
+	s.Require().NoError(err)
+
+	var ids []int
+	s.NoError(s.db.Select(&ids, "SELECT id FROM users ORDER BY id"))
+	s.Equal([]int{1, 4}, ids, "Failed nested transactions must be rolled back")
+}
+
+func (s *TxPositiveSuite) TestNestedRecoversAbortedTransaction() {
+	err := WithTx(context.Background(), s.db, nil, func(tx *sqlx.Tx) error {
+		err := WithTx(context.Background(), tx, nil, func(nested *sqlx.Tx) error {
+			_, err := nested.Exec("INSERT INTO missing VALUES (1)")
+			return err
+		})
+		s.Error(err)
+
+		_, err = tx.Exec("INSERT INTO users VALUES (1)")
+		return err
+	})
+	s.NoError(err, "Outer transaction must be usable after failed nested one")
+	assertRowsCount(s.T(), s.db, "users", 1)
+}
+
+// Run tests
+func TestIsSerializationFailure(t *testing.T) {
+	assert.True(t, IsSerializationFailure(&pq.Error{Code: "40001"}))
+	assert.True(t, IsSerializationFailure(errors.Wrap(&pq.Error{Code: "40P01"}, "deadlock")))
+	assert.False(t, IsSerializationFailure(&pq.Error{Code: "23505"}))
+	assert.False(t, IsSerializationFailure(errors.New("failed")))
+	assert.False(t, IsSerializationFailure(nil))
+}
+
+func TestTxPositiveSuite(t *testing.T) {
+	suite.Run(t, new(TxPositiveSuite))
+}
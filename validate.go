@@ -0,0 +1,200 @@
+package pgx
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// sslModes contains sslmode values supported by libpq
+// Additional info: https://www.postgresql.org/docs/current/libpq-ssl.html#LIBPQ-SSL-PROTECTION
+var sslModes = map[string]bool{
+	"":            true,
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// FieldError describes invalid option
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implements error interface
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationError aggregates all invalid options
+type ValidationError struct {
+	Errors []*FieldError
+}
+
+// Error implements error interface
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		messages = append(messages, err.Error())
+	}
+
+	return "invalid options: " + strings.Join(messages, "; ")
+}
+
+// Field returns errors of the given option
+func (e *ValidationError) Field(name string) []*FieldError {
+	var errs []*FieldError
+	for _, err := range e.Errors {
+		if err.Field == name {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// add appends error of the option
+func (e *ValidationError) add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks options and returns *ValidationError with all found problems
+func (o *Options) Validate() error {
+	errs := &ValidationError{}
+
+	o.validateConnection(errs)
+
+	validateFile(errs, "SSLCert", o.SSLCert, false)
+	validateFile(errs, "SSLKey", o.SSLKey, true)
+	validateFile(errs, "SSLRootCert", o.SSLRootCert, false)
+	validateFile(errs, "PasswordFile", o.PasswordFile, false)
+	o.validatePEM(errs)
+
+	o.validatePool(errs)
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateConnection checks server address and connection parameters
+func (o *Options) validateConnection(errs *ValidationError) {
+	if o.Host == "" && len(o.Hosts) == 0 {
+		errs.add("Host", "must not be empty")
+	}
+	for i, entry := range o.Hosts {
+		field := fmt.Sprintf("Hosts[%d]", i)
+		host, _, err := splitHostEntry(entry, o.Port)
+		switch {
+		case err != nil:
+			errs.add(field, "%v", err)
+		case host == "":
+			errs.add(field, "must not be empty")
+		case isSocketDir(host):
+			errs.add(field, "socket directory is supported only in Host")
+		}
+	}
+	if o.Port == 0 || o.Port > 65535 {
+		errs.add("Port", "must be in range 1-65535, got %d", o.Port)
+	}
+	if !sslModes[o.SSLMode] {
+		errs.add("SSLMode", "unknown mode %q", o.SSLMode)
+	}
+	if o.ConnectTimeout < 0 {
+		errs.add("ConnectTimeout", "must not be negative")
+	}
+	switch o.TargetSessionAttrs {
+	case "", targetSessionAny, targetSessionReadWrite:
+	default:
+		errs.add("TargetSessionAttrs", "unknown value %q", o.TargetSessionAttrs)
+	}
+}
+
+// validatePEM checks PEM content of certificates and key
+func (o *Options) validatePEM(errs *ValidationError) {
+	if o.SSLRootCertPEM != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(o.SSLRootCertPEM)) {
+		errs.add("SSLRootCertPEM", "does not contain certificates")
+	}
+
+	switch {
+	case o.SSLCertPEM != "" && o.SSLKeyPEM != "":
+		// Key is not included into error since it is secret
+		if _, err := tls.X509KeyPair([]byte(o.SSLCertPEM), []byte(o.SSLKeyPEM)); err != nil {
+			errs.add("SSLKeyPEM", "does not match SSLCertPEM or could not be parsed")
+		}
+	case o.SSLCertPEM != "" && o.SSLKey == "":
+		errs.add("SSLKeyPEM", "must be set with SSLCertPEM")
+	case o.SSLKeyPEM != "" && o.SSLCert == "":
+		errs.add("SSLCertPEM", "must be set with SSLKeyPEM")
+	}
+}
+
+// validatePool checks pool, database setup and retry parameters
+func (o *Options) validatePool(errs *ValidationError) {
+	if o.ConnMaxLifetime < 0 {
+		errs.add("ConnMaxLifetime", "must not be negative")
+	}
+	if o.MaxOpenConns < 0 {
+		errs.add("MaxOpenConns", "must not be negative")
+	}
+	if o.MaxIdleConns < 0 {
+		errs.add("MaxIdleConns", "must not be negative")
+	}
+	if o.MaxOpenConns > 0 && o.MaxIdleConns > o.MaxOpenConns {
+		errs.add("MaxIdleConns", "must not be greater than MaxOpenConns (%d)", o.MaxOpenConns)
+	}
+	if o.ConnectionLimit < -1 {
+		errs.add("ConnectionLimit", "must be -1 or greater")
+	}
+
+	if o.Retry.MaxAttempts < 0 {
+		errs.add("Retry.MaxAttempts", "must not be negative")
+	}
+	if o.Retry.InitialBackoff < 0 {
+		errs.add("Retry.InitialBackoff", "must not be negative")
+	}
+	if o.Retry.MaxBackoff < 0 {
+		errs.add("Retry.MaxBackoff", "must not be negative")
+	}
+	if o.Retry.Jitter < 0 || o.Retry.Jitter > 1 {
+		errs.add("Retry.Jitter", "must be in range 0-1")
+	}
+}
+
+// validateFile checks that file exists and is readable,
+// private files must not be accessible by group or others as libpq demands
+func validateFile(errs *ValidationError, field, path string, private bool) {
+	if path == "" {
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		errs.add(field, "could not be read: %v", err)
+		return
+	}
+	defer file.Close() // nolint:errcheck
+
+	info, err := file.Stat()
+	if err != nil {
+		errs.add(field, "could not be read: %v", err)
+		return
+	}
+	if info.IsDir() {
+		errs.add(field, "%s is a directory", path)
+		return
+	}
+
+	if private {
+		if err := checkPrivateFile(path, info); err != nil {
+			errs.add(field, "%v", err)
+		}
+	}
+}
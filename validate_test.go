@@ -0,0 +1,77 @@
+package pgx
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pgx")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint:errcheck
+
+	opts := &Options{
+		Host:         "localhost",
+		Port:         5432,
+		SSLMode:      "verify-full",
+		SSLCert:      writeTempFile(t, dir, "cert", 0644),
+		SSLKey:       writeTempFile(t, dir, "key", 0600),
+		SSLRootCert:  writeTempFile(t, dir, "root", 0644),
+		MaxOpenConns: 10,
+		MaxIdleConns: 10,
+	}
+	assert.NoError(t, opts.Validate(), "Must accept valid options")
+
+	opts = &Options{
+		Port:         70000,
+		SSLMode:      "on",
+		SSLCert:      filepath.Join(dir, "missing"),
+		SSLKey:       writeTempFile(t, dir, "key", 0644),
+		SSLRootCert:  dir,
+		MaxOpenConns: 5,
+		MaxIdleConns: 10,
+		Retry:        RetryPolicy{Jitter: 2},
+	}
+	err = opts.Validate()
+	require.Error(t, err)
+
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok, "Must return *ValidationError")
+
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{
+		"Host", "Port", "SSLMode", "SSLCert", "SSLKey", "SSLRootCert", "MaxIdleConns", "Retry.Jitter",
+	}, fields, "Must aggregate all errors")
+
+	assert.Len(t, validationErr.Field("Port"), 1)
+	assert.Empty(t, validationErr.Field("DBName"))
+	assert.Contains(t, err.Error(), "Port: must be in range 1-65535, got 70000")
+}
+
+func TestValidateHosts(t *testing.T) {
+	opts := &Options{Hosts: []string{"primary", "standby:5433"}, Port: 5432, TargetSessionAttrs: "read-write"}
+	assert.NoError(t, opts.Validate(), "Must accept hosts list without Host")
+
+	opts = &Options{Host: "/var/run/postgresql", Port: 5432}
+	assert.NoError(t, opts.Validate(), "Must accept socket directory")
+
+	opts = &Options{Hosts: []string{"primary:port", "", "/tmp"}, Port: 5432, TargetSessionAttrs: "primary"}
+	err := opts.Validate()
+	require.Error(t, err)
+
+	validationErr, ok := err.(*ValidationError)
+	require.True(t, ok, "Must return *ValidationError")
+	assert.Len(t, validationErr.Field("Hosts[0]"), 1, "Must reject invalid port")
+	assert.Len(t, validationErr.Field("Hosts[1]"), 1, "Must reject empty host")
+	assert.Len(t, validationErr.Field("Hosts[2]"), 1, "Must reject socket directory")
+	assert.Len(t, validationErr.Field("TargetSessionAttrs"), 1)
+	assert.Empty(t, validationErr.Field("Host"))
+}